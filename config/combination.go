@@ -0,0 +1,133 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	combineKey    = "combine"
+	weightsKey    = "weights"
+	fullCreditKey = "fullCredit"
+)
+
+// Ways to combine scores of several methods into total score
+const (
+	CombineMax      = "max"
+	CombineMin      = "min"
+	CombineWeighted = "weighted"
+)
+
+// Combination describes how scores of grading methods are combined into
+// total score
+type Combination struct {
+	// Strategy is one of CombineMax, CombineMin or CombineWeighted
+	Strategy string
+	// Weights of methods for weighted average, keys are lower case
+	Weights map[string]float64
+	// FullCredit is total score in scale from 0 to 1 from which full score
+	// is awarded
+	FullCredit float64
+}
+
+// Weight returns weight of method for weighted average, method names are
+// case insensitive
+func (c Combination) Weight(method string) float64 {
+	return c.Weights[strings.ToLower(method)]
+}
+
+// Combine calculates total score in scale from 0 to 1 from scores of methods
+// and describes how it was derived, scores in description are scaled by
+// MaxScore
+func (c Combination) Combine(scores map[string]float64) (float64, string) {
+	names := make([]string, 0, len(scores))
+	for name := range scores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return 0.0, "no methods"
+	}
+
+	var total float64
+	var terms []string
+	switch c.Strategy {
+	case CombineMax, CombineMin:
+		total = scores[names[0]]
+		for _, name := range names {
+			terms = append(terms, fmt.Sprintf(
+				"%s %.2f", name, MaxScore*scores[name],
+			))
+			if c.Strategy == CombineMax {
+				total = math.Max(total, scores[name])
+			} else {
+				total = math.Min(total, scores[name])
+			}
+		}
+	case CombineWeighted:
+		var weights float64
+		for _, name := range names {
+			weights += c.Weight(name)
+		}
+		for _, name := range names {
+			// without weights all methods count equally
+			weight := 1 / float64(len(names))
+			if weights > 0 {
+				weight = c.Weight(name) / weights
+			}
+			total += weight * scores[name]
+			terms = append(terms, fmt.Sprintf(
+				"%.2f * %s %.2f", weight, name, MaxScore*scores[name],
+			))
+		}
+	}
+	total = math.Max(0.0, math.Min(1.0, total))
+
+	label := c.Strategy
+	if c.Strategy == CombineWeighted {
+		label = "weighted average"
+	}
+	description := fmt.Sprintf(
+		"%s of (%s) = %.2f", label, strings.Join(terms, ", "), MaxScore*total,
+	)
+	if total < 1.0 && total >= c.FullCredit {
+		total = 1.0
+		description += fmt.Sprintf(
+			", at least %.2f gets full score", MaxScore*c.FullCredit,
+		)
+	}
+	return total, description
+}
+
+// readCombination reads combination under given configuration prefix,
+// settings that are not given are taken from base
+func readCombination(prefix string, base Combination) (Combination, error) {
+	c := Combination{
+		Strategy:   base.Strategy,
+		Weights:    base.Weights,
+		FullCredit: base.FullCredit,
+	}
+	if viper.IsSet(prefix + combineKey) {
+		c.Strategy = viper.GetString(prefix + combineKey)
+	}
+	if viper.IsSet(prefix + weightsKey) {
+		c.Weights = make(map[string]float64)
+		for name := range viper.GetStringMap(prefix + weightsKey) {
+			c.Weights[name] = viper.GetFloat64(prefix + weightsKey + "." + name)
+		}
+	}
+	if viper.IsSet(prefix + fullCreditKey) {
+		c.FullCredit = viper.GetFloat64(prefix + fullCreditKey)
+	}
+
+	switch c.Strategy {
+	case CombineMax, CombineMin, CombineWeighted:
+	default:
+		return c, fmt.Errorf("unknown score combination '%s'", c.Strategy)
+	}
+	return c, nil
+}
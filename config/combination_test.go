@@ -0,0 +1,131 @@
+package config
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCombine(t *testing.T) {
+	MaxScore = 100
+	scores := map[string]float64{"langDiff": 0.4, "dfaSyntaxDiff": 0.8}
+	tests := []struct {
+		name        string
+		combination Combination
+		total       float64
+		derivation  string
+	}{
+		{
+			name:        "max",
+			combination: Combination{Strategy: CombineMax, FullCredit: 1},
+			total:       0.8,
+			derivation:  "max of (dfaSyntaxDiff 80.00, langDiff 40.00) = 80.00",
+		},
+		{
+			name:        "min",
+			combination: Combination{Strategy: CombineMin, FullCredit: 1},
+			total:       0.4,
+		},
+		{
+			name: "weighted",
+			combination: Combination{
+				Strategy:   CombineWeighted,
+				Weights:    map[string]float64{"langdiff": 1, "dfasyntaxdiff": 3},
+				FullCredit: 1,
+			},
+			total: 0.25*0.4 + 0.75*0.8,
+			derivation: "weighted average of (0.75 * dfaSyntaxDiff 80.00, " +
+				"0.25 * langDiff 40.00) = 70.00",
+		},
+		{
+			name: "weights are normalized",
+			combination: Combination{
+				Strategy:   CombineWeighted,
+				Weights:    map[string]float64{"langdiff": 0.1, "dfasyntaxdiff": 0.3},
+				FullCredit: 1,
+			},
+			total: 0.25*0.4 + 0.75*0.8,
+		},
+		{
+			name: "missing weights count equally",
+			combination: Combination{
+				Strategy:   CombineWeighted,
+				FullCredit: 1,
+			},
+			total: 0.6,
+		},
+		{
+			name: "full credit",
+			combination: Combination{
+				Strategy:   CombineMax,
+				FullCredit: 0.75,
+			},
+			total:      1,
+			derivation: "= 80.00, at least 75.00 gets full score",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total, derivation := tt.combination.Combine(scores)
+			if math.Abs(total-tt.total) > 1e-9 {
+				t.Errorf("total %f, want %f", total, tt.total)
+			}
+			if !strings.Contains(derivation, tt.derivation) {
+				t.Errorf("derivation %q, want %q", derivation, tt.derivation)
+			}
+		})
+	}
+}
+
+// readConfig reads configuration from given YAML
+func readConfig(t *testing.T, yaml string) error {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+	path := filepath.Join(dir, "test.yml")
+	if err := ioutil.WriteFile(path, []byte(yaml), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return Read(strings.TrimSuffix(path, ".yml"))
+}
+
+func TestReadCombination(t *testing.T) {
+	err := readConfig(t, `
+grading:
+  combine: min
+  fullCredit: 0.9
+  assignments:
+    HW1:
+      combine: weighted
+      weights:
+        langDiff: 3
+        dfaSyntaxDiff: 1
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scores := map[string]float64{"langDiff": 0.4, "dfaSyntaxDiff": 0.8}
+	if total, _ := Grading.CombinationFor("other").Combine(scores); total != 0.4 {
+		t.Errorf("default combination gives %f, want 0.4", total)
+	}
+	// assignment names are case insensitive, settings that are not given
+	// come from the default combination
+	c := Grading.CombinationFor("hw1")
+	if c.Strategy != CombineWeighted || c.FullCredit != 0.9 {
+		t.Fatalf("got combination %+v", c)
+	}
+	if total, _ := c.Combine(scores); math.Abs(total-0.5) > 1e-9 {
+		t.Errorf("assignment combination gives %f, want 0.5", total)
+	}
+
+	err = readConfig(t, "grading:\n  combine: average\n")
+	if err == nil || !strings.Contains(err.Error(), "unknown score combination") {
+		t.Errorf("got error %v for unknown combination", err)
+	}
+}
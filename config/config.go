@@ -1,21 +1,40 @@
 package config
 
 import (
+	"fmt"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
 const (
-	maxScoreKey = "maxScore"
-	timeoutKey  = "timeout"
+	maxScoreKey        = "maxScore"
+	timeoutKey         = "timeout"
+	counterexamplesKey = "counterexamples"
+	deterministicKey   = "deterministic"
+	cacheSizeKey       = "cacheSize"
 
 	langDiffKey = "langDiff."
 	maxDepthKey = "maxDepth"
 	minDepthKey = "minDepth"
 
-	dfaDiffKey = "dfaSyntaxDiff."
+	dfaDiffKey  = "dfaSyntaxDiff."
+	maxNodesKey = "maxNodes"
+
+	densityDiffKey = "densityDiff."
+
+	regexKey     = "regex."
+	maxLengthKey = "maxLength"
+
+	inclusionKey = "inclusion."
+	tooLittleKey = "tooLittle"
+	tooMuchKey   = "tooMuch"
+
+	gradingKey     = "grading."
+	methodsKey     = "methods"
+	assignmentsKey = "assignments"
 )
 
 type langDiff struct {
@@ -26,9 +45,46 @@ type langDiff struct {
 
 type dfaDiff struct {
 	MaxDepth int
+	// MaxNodes limits number of automata checked by search, 0 means no limit
+	MaxNodes int
 	Timeout  time.Duration
 }
 
+type densityDiff struct {
+	Timeout time.Duration
+}
+
+type regex struct {
+	// MaxLength limits number of letters in regular expressions returned as
+	// feedback, 0 means no limit
+	MaxLength int
+	Timeout   time.Duration
+}
+
+type inclusion struct {
+	// TooLittle is score in scale from 0 to 1 for attempt that accepts a
+	// subset of the target language
+	TooLittle float64
+	// TooMuch is score in scale from 0 to 1 for attempt that accepts a
+	// superset of the target language
+	TooMuch float64
+}
+
+type grading struct {
+	Methods     []string
+	Combination Combination
+	Assignments map[string]Combination
+}
+
+// CombinationFor returns combination configured for assignment, or the
+// default one if assignment has no own configuration
+func (g grading) CombinationFor(assignment string) Combination {
+	if c, ok := g.Assignments[strings.ToLower(assignment)]; ok {
+		return c
+	}
+	return g.Combination
+}
+
 var (
 	// MaxScore is maximum possible score for DFA
 	MaxScore float64
@@ -36,6 +92,28 @@ var (
 	LangDiff langDiff
 	// DFADiff has all parameters to find dfa syntax mistakes
 	DFADiff dfaDiff
+	// DensityDiff has all parameters for density diff calculation
+	DensityDiff densityDiff
+	// Regex has limits for converting automata to regular expressions
+	Regex regex
+	// Inclusion has partial credit for attempts which accept too little or
+	// too much
+	Inclusion inclusion
+	// Grading lists scoring methods to run and how to combine their scores
+	Grading grading
+	// Counterexamples is number of distinguishing words returned when
+	// attempted automaton is not correct
+	Counterexamples int
+	// Deterministic makes grading reproducible: timeouts are ignored and
+	// calculation is limited only by depths and node budget, so the same
+	// submission always gets the same grade
+	Deterministic bool
+	// CacheSize is number of graded submissions whose responses are kept,
+	// 0 disables caching
+	CacheSize int
+	// Generation changes on every successful Read, so that results computed
+	// with older configuration can be told apart
+	Generation int
 )
 
 // Read prepares config file
@@ -46,6 +124,16 @@ func Read(filename string) error {
 	viper.SetDefault(langDiffKey+minDepthKey, 4)
 	viper.SetDefault(dfaDiffKey+maxDepthKey, 2)
 	viper.SetDefault(dfaDiffKey+timeoutKey, 3*time.Second)
+	viper.SetDefault(dfaDiffKey+maxNodesKey, 200000)
+	viper.SetDefault(densityDiffKey+timeoutKey, 3*time.Second)
+	viper.SetDefault(regexKey+maxLengthKey, 1000)
+	viper.SetDefault(regexKey+timeoutKey, time.Second)
+	viper.SetDefault(inclusionKey+tooLittleKey, 0.5)
+	viper.SetDefault(inclusionKey+tooMuchKey, 0.25)
+	viper.SetDefault(gradingKey+methodsKey, []string{"langDiff", "dfaSyntaxDiff"})
+	viper.SetDefault(counterexamplesKey, 5)
+	viper.SetDefault(deterministicKey, false)
+	viper.SetDefault(cacheSizeKey, 1000)
 
 	if filename != "" {
 		viper.SetConfigName(filepath.Base(filename))
@@ -57,6 +145,23 @@ func Read(filename string) error {
 		}
 	}
 
+	combination, err := readCombination(gradingKey, Combination{
+		Strategy:   CombineMax,
+		Weights:    make(map[string]float64),
+		FullCredit: 1.0,
+	})
+	if err != nil {
+		return err
+	}
+	assignments := make(map[string]Combination)
+	for name := range viper.GetStringMap(gradingKey + assignmentsKey) {
+		prefix := gradingKey + assignmentsKey + "." + name + "."
+		assignments[name], err = readCombination(prefix, combination)
+		if err != nil {
+			return fmt.Errorf("assignment '%s': %s", name, err.Error())
+		}
+	}
+
 	MaxScore = viper.GetFloat64(maxScoreKey)
 	LangDiff = langDiff{
 		MaxDepth: viper.GetInt(langDiffKey + maxDepthKey),
@@ -65,8 +170,29 @@ func Read(filename string) error {
 	}
 	DFADiff = dfaDiff{
 		MaxDepth: viper.GetInt(dfaDiffKey + maxDepthKey),
+		MaxNodes: viper.GetInt(dfaDiffKey + maxNodesKey),
 		Timeout:  viper.GetDuration(dfaDiffKey + timeoutKey),
 	}
+	DensityDiff = densityDiff{
+		Timeout: viper.GetDuration(densityDiffKey + timeoutKey),
+	}
+	Regex = regex{
+		MaxLength: viper.GetInt(regexKey + maxLengthKey),
+		Timeout:   viper.GetDuration(regexKey + timeoutKey),
+	}
+	Inclusion = inclusion{
+		TooLittle: viper.GetFloat64(inclusionKey + tooLittleKey),
+		TooMuch:   viper.GetFloat64(inclusionKey + tooMuchKey),
+	}
+	Grading = grading{
+		Methods:     viper.GetStringSlice(gradingKey + methodsKey),
+		Combination: combination,
+		Assignments: assignments,
+	}
+	Counterexamples = viper.GetInt(counterexamplesKey)
+	Deterministic = viper.GetBool(deterministicKey)
+	CacheSize = viper.GetInt(cacheSizeKey)
+	Generation++
 
 	return nil
 }
@@ -0,0 +1,78 @@
+package dfa
+
+import "fmt"
+
+// deadName names missing state of one automaton in product states
+const deadName = "-"
+
+// combine builds product automaton that accepts a word when accept returns
+// true for acceptance of the word by both automata. Alphabet of the result
+// is the union of alphabets, a word with a letter that is not in alphabet of
+// an automaton is not accepted by it. States are named "(p,q)" after the
+// pair of states, only pairs reachable from the start pair are created
+func combine(m1, m2 *DFA, accept func(a, b bool) bool) *DFA {
+	c1, c2 := m1.Compile(), m2.Compile()
+	p := NewCompiledProduct(c1, c2)
+
+	name := func(c *Compiled, s int) string {
+		if s < 0 {
+			return deadName
+		}
+		return string(c.State(s))
+	}
+	k := len(p.letters)
+	result := &Compiled{
+		states:  make([]State, len(p.pairs)),
+		letters: p.letters,
+		delta:   make([]int32, len(p.pairs)*k),
+		final:   make([]bool, len(p.pairs)),
+	}
+	used := make(map[State]bool, len(p.pairs))
+	for idx, pair := range p.pairs {
+		s := State(fmt.Sprintf(
+			"(%s,%s)", name(c1, pair[0]), name(c2, pair[1]),
+		))
+		// names of states may contain commas, so pairs may get same names
+		for used[s] {
+			s += "'"
+		}
+		used[s] = true
+		result.states[idx] = s
+		result.final[idx] = accept(p.final1[idx], p.final2[idx])
+		for a, to := range p.delta[idx] {
+			result.delta[idx*k+a] = int32(to)
+		}
+	}
+	return result.DFA()
+}
+
+// Intersect returns DFA that accepts words accepted by both automata
+func Intersect(m1, m2 *DFA) *DFA {
+	return combine(m1, m2, func(a, b bool) bool { return a && b })
+}
+
+// Union returns DFA that accepts words accepted by at least one of automata
+func Union(m1, m2 *DFA) *DFA {
+	return combine(m1, m2, func(a, b bool) bool { return a || b })
+}
+
+// Difference returns DFA that accepts words accepted by m1 but not by m2
+func Difference(m1, m2 *DFA) *DFA {
+	return combine(m1, m2, func(a, b bool) bool { return a && !b })
+}
+
+// SymmetricDifference returns DFA that accepts words accepted by exactly one
+// of automata
+func SymmetricDifference(m1, m2 *DFA) *DFA {
+	return combine(m1, m2, func(a, b bool) bool { return a != b })
+}
+
+// Complement returns DFA over the same alphabet that accepts exactly the
+// words this DFA rejects. Missing transitions lead to a new accepting state
+func (m *DFA) Complement() *DFA {
+	c := m.Compile().Complete().clone()
+	for s := range c.final {
+		c.final[s] = !c.final[s]
+	}
+	return c.DFA()
+}
@@ -0,0 +1,102 @@
+package dfa
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// sortedAlphabet returns alphabet of DFA in sorted order
+func sortedAlphabet(m *DFA) []Letter {
+	alphabet := m.Alphabet()
+	sort.Slice(alphabet, func(i, j int) bool { return alphabet[i] < alphabet[j] })
+	return alphabet
+}
+
+func TestBooleanOperations(t *testing.T) {
+	operations := []struct {
+		name    string
+		combine func(m1, m2 *DFA) *DFA
+		accept  func(a, b bool) bool
+	}{
+		{"intersect", Intersect, func(a, b bool) bool { return a && b }},
+		{"union", Union, func(a, b bool) bool { return a || b }},
+		{"difference", Difference, func(a, b bool) bool { return a && !b }},
+		{
+			"symmetric difference", SymmetricDifference,
+			func(a, b bool) bool { return a != b },
+		},
+	}
+	r := rand.New(rand.NewSource(1))
+	alphabet := []Letter{"a", "b", "c"}
+	for _, op := range operations {
+		t.Run(op.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				// the second automaton may lack some letters
+				m1 := randomDFA(r, 1+r.Intn(4), alphabet[:2])
+				m2 := randomDFA(r, 1+r.Intn(4), alphabet[:1+r.Intn(3)])
+				result := op.combine(m1, m2)
+				union := alphabet[:2]
+				if len(m2.Alphabet()) == 3 {
+					union = alphabet
+				}
+				if got := sortedAlphabet(result); !reflect.DeepEqual(got, union) {
+					t.Fatalf("alphabet %v, want %v", got, union)
+				}
+				for length := 0; length <= 5; length++ {
+					for _, w := range wordsOfLength(alphabet, length) {
+						want := op.accept(accepts(m1, w...), accepts(m2, w...))
+						if accepts(result, w...) != want {
+							t.Fatalf("pair %d: word %v accepted is %v", i, w, !want)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCombineNames(t *testing.T) {
+	// pairs ("a,b", "c") and ("a", "b,c") both print as "(a,b,c)"
+	m1, m2 := New(), New()
+	m1.SetTransition("a,b", "x", "a") // nolint: errcheck
+	m1.SetTransition("a", "x", "a")   // nolint: errcheck
+	m2.SetTransition("c", "x", "b,c") // nolint: errcheck
+	m2.SetTransition("b,c", "x", "d") // nolint: errcheck
+	m2.SetTransition("d", "x", "d")   // nolint: errcheck
+	m1.SetStartState("a,b")
+	m2.SetStartState("c")
+	m1.SetFinalStates("a")
+	m2.SetFinalStates("b,c")
+
+	result := Intersect(m1, m2)
+	if n := len(result.States()); n != 3 {
+		t.Fatalf("got states %v, want 3 states", result.States())
+	}
+	if result.StartState() != "(a,b,c)" || !result.HasState("(a,b,c)'") {
+		t.Errorf("got states %v", result.States())
+	}
+	if !accepts(result, "x") || accepts(result, "x", "x") {
+		t.Error("intersection accepts wrong words")
+	}
+}
+
+func TestComplement(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	alphabet := []Letter{"a", "b"}
+	for i := 0; i < 100; i++ {
+		m := randomDFA(r, 1+r.Intn(4), alphabet)
+		complement := m.Complement()
+		if got := sortedAlphabet(complement); !reflect.DeepEqual(got, alphabet) {
+			t.Fatalf("alphabet %v, want %v", got, alphabet)
+		}
+		for length := 0; length <= 5; length++ {
+			for _, w := range wordsOfLength(alphabet, length) {
+				if accepts(complement, w...) == accepts(m, w...) {
+					t.Fatalf("automaton %d: complement agrees on word %v", i, w)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package dfa
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+)
+
+// Key describes structure of automaton without names of states: alphabet
+// size, start state, accepting states and transitions. Automata with the
+// same alphabet have the same key exactly when they have the same numbering
+// of states and transitions
+func (c *Compiled) Key() string {
+	b := make([]byte, 0, 8+len(c.states)*(1+4*len(c.letters)))
+	put := func(v int) {
+		b = append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+	}
+	put(len(c.letters))
+	put(c.start)
+	for s := range c.states {
+		if c.final[s] {
+			b = append(b, 1)
+		} else {
+			b = append(b, 0)
+		}
+		for a := range c.letters {
+			put(c.Next(s, a))
+		}
+	}
+	return string(b)
+}
+
+// CanonicalKey is the key of automaton with reachable states renumbered in
+// BFS order over sorted letters, unreachable states follow in their current
+// order. Isomorphic automata get the same canonical key whatever numbering
+// of states they have. Unlike Canonical, automata are not minimized, so
+// automata of the same language but different structure get different keys
+func (c *Compiled) CanonicalKey() string {
+	k := len(c.letters)
+	index := make([]int, len(c.states))
+	for s := range index {
+		index[s] = -1
+	}
+	order := []int{c.start}
+	index[c.start] = 0
+	for i := 0; i < len(order); i++ {
+		for a := 0; a < k; a++ {
+			to := c.Next(order[i], a)
+			if to >= 0 && index[to] == -1 {
+				index[to] = len(order)
+				order = append(order, to)
+			}
+		}
+	}
+	for s := range c.states {
+		if index[s] == -1 {
+			index[s] = len(order)
+			order = append(order, s)
+		}
+	}
+
+	renumbered := &Compiled{
+		letters: c.letters,
+		states:  make([]State, len(order)),
+		delta:   make([]int32, len(c.delta)),
+		final:   make([]bool, len(order)),
+	}
+	for idx, s := range order {
+		renumbered.final[idx] = c.final[s]
+		for a := 0; a < k; a++ {
+			to := c.Next(s, a)
+			if to >= 0 {
+				to = index[to]
+			}
+			renumbered.delta[idx*k+a] = int32(to)
+		}
+	}
+	return renumbered.Key()
+}
+
+// Hash returns hex encoded SHA-256 of alphabet, names of states and
+// structure of automaton. Hash of canonical automaton depends only on its
+// language
+func (c *Compiled) Hash() string {
+	h := sha256.New()
+	var size [8]byte
+	write := func(s string) {
+		binary.LittleEndian.PutUint64(size[:], uint64(len(s)))
+		h.Write(size[:])   // nolint: errcheck,gas
+		h.Write([]byte(s)) // nolint: errcheck,gas
+	}
+	for _, l := range c.letters {
+		write(string(l))
+	}
+	for _, s := range c.states {
+		write(string(s))
+	}
+	write(c.Key())
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Canonical returns the minimal complete automaton of the same language with
+// states named by their index in BFS order over sorted letters, so automata
+// accepting the same language over the same alphabet get equal canonical
+// forms
+func (c *Compiled) Canonical() *Compiled {
+	// minimization can fail only when context is done
+	min, _ := c.Complete().Minimize(context.Background()) // nolint: gas
+	for s := range min.states {
+		min.states[s] = State(strconv.Itoa(s))
+	}
+	return min
+}
+
+// Canonical returns the minimal complete DFA of the same language with
+// states named "0", "1", ... in BFS order over sorted letters
+func (m *DFA) Canonical() *DFA {
+	return m.Compile().Canonical().DFA()
+}
+
+// LanguageHash returns stable hash of the language of DFA and its alphabet,
+// DFAs accepting the same language over the same alphabet get the same hash
+func (m *DFA) LanguageHash() string {
+	return m.Compile().Canonical().Hash()
+}
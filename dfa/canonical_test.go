@@ -0,0 +1,70 @@
+package dfa
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestCanonicalKey(t *testing.T) {
+	m := New()
+	m.SetTransition("p", "a", "q")
+	m.SetTransition("q", "a", "p")
+	m.SetTransition("q", "b", "r")
+	m.SetStartState("p")
+	m.SetFinalStates("r")
+	c := m.Compile()
+
+	// isomorphic automaton with start state numbered 1
+	other := New()
+	other.SetTransition("x", "a", "y")
+	other.SetTransition("y", "a", "z")
+	other.SetLetter("b")
+	other.SetStartState("x")
+	renumbered := other.Compile().WithStart(1).WithTransition(2, 0, 1).
+		WithTransition(2, 1, 0).WithTransition(0, 0, -1).WithFinal(0, true)
+
+	if c.Key() == renumbered.Key() {
+		t.Fatal("keys of differently numbered automata are equal")
+	}
+	if c.CanonicalKey() != renumbered.CanonicalKey() {
+		t.Error("canonical keys of isomorphic automata differ")
+	}
+	if c.CanonicalKey() == renumbered.WithFinal(0, false).CanonicalKey() {
+		t.Error("canonical keys of different automata are equal")
+	}
+}
+
+func TestLanguageHash(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	alphabet := []Letter{"a", "b"}
+	for i := 0; i < 200; i++ {
+		m1 := randomDFA(r, 1+r.Intn(4), alphabet)
+		m2 := randomDFA(r, 1+r.Intn(4), alphabet)
+		if r.Intn(2) == 0 {
+			m2, _ = renamed(r, m1)
+		}
+		_, differ := DistinguishingWord(m1, m2)
+		if hashDiffer := m1.LanguageHash() != m2.LanguageHash(); hashDiffer != differ {
+			t.Fatalf(
+				"pair %d: languages differ is %v, hashes differ is %v",
+				i, differ, hashDiffer,
+			)
+		}
+	}
+
+	// the same language with more states and in different order
+	m := New()
+	m.SetTransition("e", "a", "o")
+	m.SetTransition("o", "a", "e2")
+	m.SetTransition("e2", "a", "o")
+	m.SetLetter("b")
+	m.SetStartState("e")
+	m.SetFinalStates("o")
+	if m.LanguageHash() != oddAs().LanguageHash() {
+		t.Error("hashes of equivalent automata differ")
+	}
+	m.SetLetter("c")
+	if m.LanguageHash() == oddAs().LanguageHash() {
+		t.Error("hashes of automata over different alphabets are equal")
+	}
+}
@@ -0,0 +1,286 @@
+package dfa
+
+import (
+	"sort"
+	"strconv"
+)
+
+// Compiled is an immutable form of DFA for calculations that process many
+// automata or many words. States and letters are numbered from 0, letters in
+// sorted order. Transitions are kept in a single table indexed by
+// state*letters+letter, missing transitions are -1. Compiled automaton is
+// safe for concurrent use, changes create new automata
+type Compiled struct {
+	states  []State
+	letters []Letter
+	delta   []int32
+	final   []bool
+	start   int
+}
+
+// Compile converts DFA into compiled form. States are numbered in the order
+// they are reached from the start state when letters are tried in sorted
+// order, so the start state is 0, unreachable states follow in sorted order
+func (m *DFA) Compile() *Compiled {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	order := m.bfsOrder()
+	c := &Compiled{
+		states:  make([]State, len(order)),
+		letters: make([]Letter, 0, len(m.e)),
+	}
+	for s, idx := range order {
+		c.states[idx] = s
+	}
+	var unreachable []State
+	for s := range m.q {
+		if _, ok := order[s]; !ok {
+			unreachable = append(unreachable, s)
+		}
+	}
+	sort.Slice(unreachable, func(i, j int) bool {
+		return unreachable[i] < unreachable[j]
+	})
+	for _, s := range unreachable {
+		order[s] = len(c.states)
+		c.states = append(c.states, s)
+	}
+
+	for l := range m.e {
+		c.letters = append(c.letters, l)
+	}
+	sort.Slice(c.letters, func(i, j int) bool {
+		return c.letters[i] < c.letters[j]
+	})
+
+	k := len(c.letters)
+	c.delta = make([]int32, len(c.states)*k)
+	c.final = make([]bool, len(c.states))
+	for idx, s := range c.states {
+		c.final[idx] = m.f[s]
+		for a, l := range c.letters {
+			c.delta[idx*k+a] = -1
+			if to := m.d[domainElement{s: s, l: l}]; to != nil {
+				c.delta[idx*k+a] = int32(order[*to])
+			}
+		}
+	}
+	return c
+}
+
+// DFA converts compiled automaton back into DFA
+func (c *Compiled) DFA() *DFA {
+	m := New()
+	c.fill(m)
+	return m
+}
+
+// fill replaces contents of m with this automaton, caller must hold the lock
+// of m if it is shared
+func (c *Compiled) fill(m *DFA) {
+	m.q = make(map[State]bool, len(c.states))
+	m.e = make(map[Letter]bool, len(c.letters))
+	m.f = make(map[State]bool)
+	m.d = make(map[domainElement]*State, len(c.delta))
+	for _, l := range c.letters {
+		m.e[l] = true
+	}
+	for s, name := range c.states {
+		m.q[name] = true
+		if c.final[s] {
+			m.f[name] = true
+		}
+		for a, l := range c.letters {
+			if to := c.Next(s, a); to >= 0 {
+				target := c.states[to]
+				m.d[domainElement{s: name, l: l}] = &target
+			}
+		}
+	}
+	m.q0 = c.states[c.start]
+}
+
+// NumStates returns number of states
+func (c *Compiled) NumStates() int {
+	return len(c.states)
+}
+
+// NumLetters returns size of the alphabet
+func (c *Compiled) NumLetters() int {
+	return len(c.letters)
+}
+
+// State returns name of state s
+func (c *Compiled) State(s int) State {
+	return c.states[s]
+}
+
+// Letter returns letter with index a
+func (c *Compiled) Letter(a int) Letter {
+	return c.letters[a]
+}
+
+// Letters returns sorted alphabet
+func (c *Compiled) Letters() []Letter {
+	result := make([]Letter, len(c.letters))
+	copy(result, c.letters)
+	return result
+}
+
+// LetterIndex finds index of letter, second return value is false if letter
+// is not in the alphabet
+func (c *Compiled) LetterIndex(l Letter) (int, bool) {
+	a := sort.Search(len(c.letters), func(i int) bool {
+		return c.letters[i] >= l
+	})
+	return a, a < len(c.letters) && c.letters[a] == l
+}
+
+// Start returns the start state
+func (c *Compiled) Start() int {
+	return c.start
+}
+
+// Next returns state reached from s with letter a, or -1 if transition is
+// missing
+func (c *Compiled) Next(s, a int) int {
+	return int(c.delta[s*len(c.letters)+a])
+}
+
+// IsFinal checks if state s is accepting
+func (c *Compiled) IsFinal(s int) bool {
+	return c.final[s]
+}
+
+// Walk returns states visited while reading word from the start state. Walk
+// stops before a letter that has no transition or is not in the alphabet
+func (c *Compiled) Walk(word Word) []int {
+	walk := []int{c.start}
+	for _, l := range word {
+		a, ok := c.LetterIndex(l)
+		if !ok {
+			break
+		}
+		to := c.Next(walk[len(walk)-1], a)
+		if to < 0 {
+			break
+		}
+		walk = append(walk, to)
+	}
+	return walk
+}
+
+// NewStateName returns name that is not yet used by any state, names are
+// chosen the same way as by DFA.GetNewState
+func (c *Compiled) NewStateName() State {
+	used := make(map[State]bool, len(c.states))
+	for _, s := range c.states {
+		used[s] = true
+	}
+	for num := 0; ; num++ {
+		s := State("auto_created_" + strconv.Itoa(num))
+		if !used[s] {
+			return s
+		}
+	}
+}
+
+func (c *Compiled) clone() *Compiled {
+	result := &Compiled{
+		states:  make([]State, len(c.states)),
+		letters: c.letters,
+		delta:   make([]int32, len(c.delta)),
+		final:   make([]bool, len(c.final)),
+		start:   c.start,
+	}
+	copy(result.states, c.states)
+	copy(result.delta, c.delta)
+	copy(result.final, c.final)
+	return result
+}
+
+// WithStart returns copy of automaton with start state s
+func (c *Compiled) WithStart(s int) *Compiled {
+	result := c.clone()
+	result.start = s
+	return result
+}
+
+// WithFinal returns copy of automaton where state s is accepting or not
+func (c *Compiled) WithFinal(s int, final bool) *Compiled {
+	result := c.clone()
+	result.final[s] = final
+	return result
+}
+
+// WithTransition returns copy of automaton where state s goes to state to
+// with letter a
+func (c *Compiled) WithTransition(s, a, to int) *Compiled {
+	result := c.clone()
+	result.delta[s*len(c.letters)+a] = int32(to)
+	return result
+}
+
+// WithState returns copy of automaton with new non-accepting state that
+// goes to itself with every letter, new state gets the last index
+func (c *Compiled) WithState(name State) *Compiled {
+	result := c.clone()
+	s := len(c.states)
+	result.states = append(result.states, name)
+	result.final = append(result.final, false)
+	for range c.letters {
+		result.delta = append(result.delta, int32(s))
+	}
+	return result
+}
+
+// Complete returns automaton where every missing transition leads to a new
+// non-accepting state, or the same automaton if no transition is missing
+func (c *Compiled) Complete() *Compiled {
+	missing := false
+	for _, to := range c.delta {
+		if to < 0 {
+			missing = true
+			break
+		}
+	}
+	if !missing {
+		return c
+	}
+
+	result := c.WithState(c.NewStateName())
+	sink := int32(len(c.states))
+	for idx, to := range result.delta {
+		if to < 0 {
+			result.delta[idx] = sink
+		}
+	}
+	return result
+}
+
+// equal checks if automata have the same alphabet, numbering of states and
+// transitions. Minimized automata are equal exactly when they accept the
+// same language, because their states are numbered in BFS order
+func (c *Compiled) equal(o *Compiled) bool {
+	if c.start != o.start || len(c.letters) != len(o.letters) ||
+		len(c.states) != len(o.states) {
+		return false
+	}
+	for a := range c.letters {
+		if c.letters[a] != o.letters[a] {
+			return false
+		}
+	}
+	for s := range c.final {
+		if c.final[s] != o.final[s] {
+			return false
+		}
+	}
+	for idx := range c.delta {
+		if c.delta[idx] != o.delta[idx] {
+			return false
+		}
+	}
+	return true
+}
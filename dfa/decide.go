@@ -0,0 +1,176 @@
+package dfa
+
+// shortestWord finds the shortest word leading from start state of a graph
+// to a state for which found returns true, among words of the same length
+// the lexicographically smallest is returned. Graph has n states, next
+// returns state reached with letter a or -1
+func shortestWord(
+	start, n int,
+	letters []Letter,
+	next func(s, a int) int,
+	found func(s int) bool,
+) (Word, bool) {
+	type step struct{ from, a int }
+	parent := make([]step, n)
+	visited := make([]bool, n)
+	visited[start] = true
+	queue := []int{start}
+	for i := 0; i < len(queue); i++ {
+		s := queue[i]
+		if found(s) {
+			var word Word
+			for s != start {
+				word = append(word, letters[parent[s].a])
+				s = parent[s].from
+			}
+			for l, r := 0, len(word)-1; l < r; l, r = l+1, r-1 {
+				word[l], word[r] = word[r], word[l]
+			}
+			return word, true
+		}
+		for a := range letters {
+			to := next(s, a)
+			if to >= 0 && !visited[to] {
+				visited[to] = true
+				parent[to] = step{from: s, a: a}
+				queue = append(queue, to)
+			}
+		}
+	}
+	return nil, false
+}
+
+// shortestAccepted returns the shortest accepted word
+func (c *Compiled) shortestAccepted() (Word, bool) {
+	return shortestWord(
+		c.start, len(c.states), c.letters, c.Next,
+		func(s int) bool { return c.final[s] },
+	)
+}
+
+// Accepts checks if word is accepted, words with letters that are not in the
+// alphabet are rejected
+func (c *Compiled) Accepts(word Word) bool {
+	walk := c.Walk(word)
+	return len(walk) == len(word)+1 && c.final[walk[len(walk)-1]]
+}
+
+// Accepts checks if word is accepted by DFA, words with letters that are not
+// in the alphabet are rejected
+func (m *DFA) Accepts(word Word) bool {
+	return m.Compile().Accepts(word)
+}
+
+// IsEmpty checks if DFA accepts no words, otherwise the shortest accepted
+// word is returned as witness
+func (m *DFA) IsEmpty() (bool, Word) {
+	word, ok := m.Compile().shortestAccepted()
+	return !ok, word
+}
+
+// IsUniversal checks if DFA accepts all words over its alphabet, otherwise
+// the shortest rejected word is returned as witness
+func (m *DFA) IsUniversal() (bool, Word) {
+	word, ok := m.Complement().Compile().shortestAccepted()
+	return !ok, word
+}
+
+// IsFinite checks if DFA accepts finitely many words. Otherwise witness is
+// an accepted word that goes through a cycle, so repeating part of it gives
+// infinitely many accepted words
+func (m *DFA) IsFinite() (bool, Word) {
+	c := m.Compile()
+	n, k := len(c.states), len(c.letters)
+
+	// only states that are reachable and can reach a final state matter,
+	// Compile numbers states in BFS order, so predecessors come first
+	reverse := make([][]int, n)
+	reachable := make([]bool, n)
+	reachable[c.start] = true
+	for i := 0; i < n; i++ {
+		if !reachable[i] {
+			continue
+		}
+		for a := 0; a < k; a++ {
+			to := c.Next(i, a)
+			if to >= 0 {
+				reachable[to] = true
+				reverse[to] = append(reverse[to], i)
+			}
+		}
+	}
+	useful := make([]bool, n)
+	var stack []int
+	for s := 0; s < n; s++ {
+		if reachable[s] && c.final[s] {
+			useful[s] = true
+			stack = append(stack, s)
+		}
+	}
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, from := range reverse[s] {
+			if !useful[from] {
+				useful[from] = true
+				stack = append(stack, from)
+			}
+		}
+	}
+	if !useful[c.start] {
+		return true, nil
+	}
+
+	// depth first search for a cycle among useful states, path holds letters
+	// leading from the start state to the current state
+	const (
+		unseen = iota
+		open
+		done
+	)
+	color := make([]int, n)
+	var path Word
+	var loop int
+	var visit func(s int) bool
+	visit = func(s int) bool {
+		color[s] = open
+		for a := 0; a < k; a++ {
+			to := c.Next(s, a)
+			if to < 0 || !useful[to] || color[to] == done {
+				continue
+			}
+			path = append(path, c.letters[a])
+			if color[to] == open {
+				loop = to
+				return true
+			}
+			if visit(to) {
+				return true
+			}
+			path = path[:len(path)-1]
+		}
+		color[s] = done
+		return false
+	}
+	if !visit(c.start) {
+		return true, nil
+	}
+
+	// path ends with a cycle from loop to itself, complete it with a word
+	// that leads from loop to a final state
+	suffix, _ := c.WithStart(loop).shortestAccepted()
+	return false, append(path, suffix...)
+}
+
+// Subset checks if every word accepted by m1 is accepted by m2, otherwise
+// the shortest word accepted by m1 but not by m2 is returned as witness
+func Subset(m1, m2 *DFA) (bool, Word) {
+	p := NewProduct(m1, m2)
+	word, ok := shortestWord(
+		0, p.Size(),
+		p.letters,
+		func(s, a int) int { return p.delta[s][a] },
+		func(s int) bool { return p.final1[s] && !p.final2[s] },
+	)
+	return !ok, word
+}
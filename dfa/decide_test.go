@@ -0,0 +1,121 @@
+package dfa
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// firstWord finds by enumeration the shortest and lexicographically smallest
+// word of length at most maxLength for which found returns true
+func firstWord(
+	alphabet []Letter,
+	maxLength int,
+	found func(w []Letter) bool,
+) ([]Letter, bool) {
+	for length := 0; length <= maxLength; length++ {
+		for _, w := range wordsOfLength(alphabet, length) {
+			if found(w) {
+				return w, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// sameWord compares words, nil and empty words are the same
+func sameWord(w1 Word, w2 []Letter) bool {
+	return len(w1) == len(w2) && (len(w1) == 0 || reflect.DeepEqual([]Letter(w1), w2))
+}
+
+func TestIsEmptyIsUniversal(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	alphabet := []Letter{"a", "b"}
+	for i := 0; i < 200; i++ {
+		n := 1 + r.Intn(4)
+		m := randomDFA(r, n, alphabet)
+
+		// the shortest accepted word visits every state at most once, the
+		// shortest rejected one may also end in the sink
+		want, ok := firstWord(alphabet, n, func(w []Letter) bool {
+			return accepts(m, w...)
+		})
+		empty, word := m.IsEmpty()
+		if empty == ok || ok && !sameWord(word, want) {
+			t.Fatalf("automaton %d: got %v, %v, want witness %v", i, empty, word, want)
+		}
+
+		want, ok = firstWord(alphabet, n+1, func(w []Letter) bool {
+			return !accepts(m, w...)
+		})
+		universal, word := m.IsUniversal()
+		if universal == ok || ok && !sameWord(word, want) {
+			t.Fatalf(
+				"automaton %d: got %v, %v, want witness %v", i, universal, word, want,
+			)
+		}
+	}
+}
+
+func TestIsFinite(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	alphabet := []Letter{"a", "b"}
+	for i := 0; i < 200; i++ {
+		n := 1 + r.Intn(4)
+		m := randomDFA(r, n, alphabet)
+
+		// language is infinite iff it has a word with length from n to 2n-1
+		var infinite bool
+		for length := n; length < 2*n && !infinite; length++ {
+			for _, w := range wordsOfLength(alphabet, length) {
+				infinite = infinite || accepts(m, w...)
+			}
+		}
+		finite, word := m.IsFinite()
+		if finite == infinite {
+			t.Fatalf("automaton %d: finite is %v", i, finite)
+		}
+		if finite {
+			if word != nil {
+				t.Fatalf("automaton %d: got witness %v for finite language", i, word)
+			}
+			continue
+		}
+		// witness is accepted and walks through some state twice
+		c := m.Compile()
+		walk := c.Walk(word)
+		seen := make(map[int]bool)
+		var cycle bool
+		for _, s := range walk {
+			cycle = cycle || seen[s]
+			seen[s] = true
+		}
+		if !c.Accepts(word) || !cycle {
+			t.Fatalf("automaton %d: witness %v does not pump", i, word)
+		}
+	}
+}
+
+func TestSubset(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	alphabet := []Letter{"a", "b"}
+	for i := 0; i < 200; i++ {
+		m1 := randomDFA(r, 1+r.Intn(2), alphabet)
+		m2 := randomDFA(r, 1+r.Intn(2), alphabet)
+		if r.Intn(2) == 0 {
+			// make inclusion more likely
+			m1 = Intersect(m1, m2)
+		}
+
+		// the shortest counterexample visits every pair of states at most
+		// once, there are at most 3 * 3 pairs with sinks, intersection with
+		// m2 adds no pairs
+		want, ok := firstWord(alphabet, 8, func(w []Letter) bool {
+			return accepts(m1, w...) && !accepts(m2, w...)
+		})
+		subset, word := Subset(m1, m2)
+		if subset == ok || ok && !sameWord(word, want) {
+			t.Fatalf("pair %d: got %v, %v, want witness %v", i, subset, word, want)
+		}
+	}
+}
@@ -3,15 +3,14 @@ package dfa
 // From github.com/lytics/dfa
 
 import (
-	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"sync"
 )
 
-var errDone = errors.New("out of inputs")
-
 // State describes single state in DFA
 type State string
 
@@ -26,7 +25,8 @@ func (l Letter) String() string {
 	return string(l)
 }
 
-// EOF is used to mark end of input
+// EOF is used to mark end of input for Run, so it is not allowed as a letter
+// by ValidateAlphabet
 var EOF Letter = "EOF"
 
 // DFA describes a dfa with some helper fields
@@ -37,7 +37,6 @@ type DFA struct {
 	q0 State                    // Start State
 	f  map[State]bool           // Final States
 
-	input  chan Letter   // Inputs to the DFA
 	stop   chan struct{} // Stops the DFA
 	logger func(State)   // Logger for transitions
 
@@ -216,135 +215,78 @@ func (m *DFA) Determinize() error {
 	return nil
 }
 
-// Minimize removes obsolete transitions and minimizes the DFA
-func (m *DFA) Minimize() {
-	m.removeUnreachable()
-	m.mergeNonDistinguishable()
-}
-
-func (m *DFA) removeUnreachable() {
-	// let reachable_states := {q0};
-	// let new_states := {q0};
-	// do {
-	//     temp := the empty set;
-	//     for each q in new_states do
-	//         for each c in Σ do
-	//             temp := temp ∪ {p such that p = δ(q,c)};
-	//         end;
-	//     end;
-	//     new_states := temp \ reachable_states;
-	//     reachable_states := reachable_states ∪ new_states;
-	// } while (new_states ≠ the empty set);
-	// unreachable_states := Q \ reachable_states;
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	reachable := make(map[State]bool)
-	reachable[m.q0] = true
-	newStates := make(map[State]bool)
-	newStates[m.q0] = true
-	for len(newStates) != 0 {
-		reached := make(map[State]bool)
-		for s := range newStates {
-			for l := range m.e {
-				reached[*m.d[domainElement{s: s, l: l}]] = true
-			}
-		}
-		for s := range reachable {
-			delete(reached, s)
-		}
-		newStates = reached
-		for s := range newStates {
-			reachable[s] = true
-		}
-	}
-	for s := range m.q {
-		if !reachable[s] {
-			delete(m.f, s)
-			delete(m.q, s)
-
-			for l := range m.e {
-				delete(m.d, domainElement{s: s, l: l})
-			}
-		}
+// Minimize removes obsolete transitions and minimizes the DFA, see
+// Compiled.Minimize. If ctx is done before minimization finishes, its error
+// is returned and the DFA is left unchanged
+func (m *DFA) Minimize(ctx context.Context) error {
+	min, err := m.Compile().Minimize(ctx)
+	if err != nil {
+		return err
 	}
-}
 
-type doubleState struct {
-	a, b State
-}
-
-func (m *DFA) mergeNonDistinguishable() {
-	distinguishable := m.getDistinguishable()
-	m.deleteIndistinguishable(distinguishable)
-}
-
-func (m *DFA) getDistinguishable() map[doubleState]bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	min.fill(m)
+	return nil
+}
 
-	distinguishable := make(map[doubleState]bool)
-	for f := range m.f {
-		for s := range m.q {
-			if !m.f[s] {
-				distinguishable[doubleState{a: s, b: f}] = true
-				distinguishable[doubleState{a: f, b: s}] = true
-			}
-		}
+// coReachable returns states from which some final state can be reached,
+// caller must hold the lock
+func (m *DFA) coReachable() map[State]bool {
+	reverse := make(map[State][]State)
+	for de, to := range m.d {
+		reverse[*to] = append(reverse[*to], de.s)
 	}
 
-	var allDone bool
-	for !allDone {
-		allDone = true
-		for s1 := range m.q {
-			for s2 := range m.q {
-				if distinguishable[doubleState{a: s1, b: s2}] {
-					continue
-				}
-				for l := range m.e {
-					pair := doubleState{
-						a: *m.d[domainElement{s: s1, l: l}],
-						b: *m.d[domainElement{s: s2, l: l}],
-					}
-					if distinguishable[pair] {
-						allDone = false
-						distinguishable[doubleState{a: s1, b: s2}] = true
-						distinguishable[doubleState{a: s2, b: s1}] = true
-					}
-				}
+	live := make(map[State]bool)
+	stack := make([]State, 0, len(m.f))
+	for s := range m.f {
+		live[s] = true
+		stack = append(stack, s)
+	}
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, from := range reverse[s] {
+			if !live[from] {
+				live[from] = true
+				stack = append(stack, from)
 			}
 		}
 	}
-	return distinguishable
+	return live
 }
 
-func (m *DFA) deleteIndistinguishable(distinguishable map[doubleState]bool) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	for s1 := range m.q {
-		for s2 := range m.q {
-			if s1 == s2 {
+// bfsOrder numbers states in the order they are reached from the start state
+// when letters are tried in sorted order, caller must hold the lock
+func (m *DFA) bfsOrder() map[State]int {
+	letters := make([]Letter, 0, len(m.e))
+	for l := range m.e {
+		letters = append(letters, l)
+	}
+	sort.Slice(letters, func(i, j int) bool { return letters[i] < letters[j] })
+
+	order := map[State]int{m.q0: 0}
+	queue := []State{m.q0}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		for _, l := range letters {
+			to := m.d[domainElement{s: s, l: l}]
+			if to == nil {
 				continue
 			}
-			if !distinguishable[doubleState{a: s1, b: s2}] {
-				for k, v := range m.d {
-					if *v == s2 {
-						*v = s1
-					}
-					if k.s == s2 {
-						delete(m.d, k)
-						m.d[domainElement{s: s1, l: k.l}] = v
-					}
-				}
-				delete(m.f, s2)
-				if m.q0 == s2 {
-					m.q0 = s1
-				}
-				delete(m.q, s2)
+			if _, ok := order[*to]; !ok {
+				order[*to] = len(order)
+				queue = append(queue, *to)
 			}
 		}
 	}
+	return order
+}
+
+type doubleState struct {
+	a, b State
 }
 
 // TransitionTarget returns state after executing given transition
@@ -363,89 +305,95 @@ func (m *DFA) TransitionTarget(s State, l Letter) (State, error) {
 	)
 }
 
-func (m *DFA) doTransition(s State) (State, error) {
-	l := <-m.input
-	if l == EOF {
-		return s, errDone
-	}
-	// Reject upfront if letter is not in alphabet.
-	m.mu.Lock()
-	if !m.e[l] {
-		m.mu.Unlock()
-		return s, fmt.Errorf("letter '%v' is not in alphabet", l)
-	}
-	m.mu.Unlock()
-	// Check the transition function.
-	next, err := m.TransitionTarget(s, l)
-	if err != nil {
-		return s, nil
-	}
-	m.logger(next)
-	return next, nil
-}
-
-// Run the DFA, blocking until Stop is called or inputs run out.
-// Returns the last state and true if the last state was a final state.
-// Use EOF to indicate end of inout
+// Run the DFA, blocking until Stop is called or inputs run out, either by
+// receiving EOF or by closing the channel. Letters are read as they arrive
+// and transition logger, if set, is called with every visited state. A
+// letter that is not in the alphabet stops the run with an error, a letter
+// without transition halts it as in Simulate, the remaining inputs are then
+// read and the word is rejected. Returns the last state and true if the last
+// state was a final state. Use EOF to indicate end of inout
 func (m *DFA) Run(inputs chan Letter) (State, bool, error) {
-	m.input = inputs
 	valid, err := m.Valid()
 	if !valid {
 		return State(""), false, err
 	}
 
-	// Run the DFA.
-	// The current state, starts at q0.
-	s := m.q0
-	m.logger(s)
+	c := m.Compile()
+	m.mu.Lock()
+	stop := m.stop
+	logger := m.logger
+	m.mu.Unlock()
+
+	s, halted := c.Start(), false
+	if logger != nil {
+		logger(c.State(s))
+	}
+read:
 	for {
-		var stopnow bool
+		// stop takes priority over inputs that are already waiting
 		select {
-		case <-m.stop:
-			stopnow = true
+		case <-stop:
+			break read
 		default:
 		}
-		if stopnow {
-			break
-		}
-		s, err = m.doTransition(s)
-		if err == errDone {
-			break
-		}
-		if err != nil {
-			return State(""), false, err
+		select {
+		case <-stop:
+			break read
+		case l, ok := <-inputs:
+			if !ok || l == EOF {
+				break read
+			}
+			a, ok := c.LetterIndex(l)
+			if !ok {
+				m.restart(stop)
+				return State(""), false,
+					fmt.Errorf("letter '%v' is not in alphabet", l)
+			}
+			if halted {
+				continue
+			}
+			to := c.Next(s, a)
+			if to < 0 {
+				halted = true
+				continue
+			}
+			s = to
+			if logger != nil {
+				logger(c.State(s))
+			}
 		}
 	}
-	// check if the current state is accepted or rejected by the DFA.
-	var accepted bool
-	if m.IsFinal(s) {
-		accepted = true
-	}
-	return s, accepted, nil
+	m.restart(stop)
+
+	return c.State(s), !halted && c.IsFinal(s), nil
 }
 
-// Stop the DFA.
-func (m *DFA) Stop() {
-	close(m.stop)
+// restart replaces stop channel closed by Stop, so that the DFA may be run
+// again
+func (m *DFA) restart(stop chan struct{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	select {
+	case <-stop:
+		if m.stop == stop {
+			m.stop = make(chan struct{})
+		}
+	default:
+	}
 }
 
-// GraphViz representation string which can be copy-n-pasted into
-// any online tool like http://graphs.grevian.org/graph to get
-// a diagram of the DFA.
-func (m *DFA) GraphViz() string {
+// Stop the DFA, calling Stop when DFA is not running makes the next Run
+// stop immediately
+func (m *DFA) Stop() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	var buf bytes.Buffer
-	buf.WriteString("digraph {\n")
-	for do, to := range m.d {
-		buf.WriteString(fmt.Sprintf(
-			"    \"%s\" -> \"%s\"[label=\"%s\"];\n",
-			do.s, to, do.l,
-		))
+	select {
+	case <-m.stop:
+	default:
+		close(m.stop)
 	}
-	buf.WriteString("}")
-	return buf.String()
 }
 
 // Copy returns copy of original dfa
@@ -473,64 +421,18 @@ func (m *DFA) Copy() *DFA {
 	return c
 }
 
-// equiv checks if automata is equivalent with second automata t
-// assumes DFA's are minimized - equivalent up to rewording of states
-func (m *DFA) equiv(t *DFA) bool {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	if len(m.q) != len(t.q) || len(m.e) != len(t.e) {
-		return false
-	}
-	for l := range m.e {
-		if !t.e[l] {
-			return false
-		}
-	}
-	mapping := make(map[State]State)
-	mapping[m.q0] = t.q0
-	check := make(chan State, len(m.q))
-	defer close(check)
-	check <- m.q0
-
-	for i := 0; i < len(m.q); i++ {
-		s := <-check
-		for l := range m.e {
-			mTo := *m.d[domainElement{l: l, s: s}]
-			tTo := *t.d[domainElement{l: l, s: mapping[s]}]
-			if v, ok := mapping[mTo]; ok {
-				if v != tTo {
-					return false
-				}
-			} else if m.f[mTo] != t.f[tTo] {
-				return false
-			} else {
-				mapping[mTo] = tTo
-				check <- mTo
-			}
-		}
-	}
-
-	return true
-}
-
 // Compare minimizes both automata and compares them
-func Compare(m1, m2 *DFA) (bool, error) {
-	var err error
-	m1Min := m1.Copy()
-	err = m1Min.Determinize()
+func Compare(ctx context.Context, m1, m2 *DFA) (bool, error) {
+	m1Min, err := m1.Compile().Complete().Minimize(ctx)
 	if err != nil {
 		return false, err
 	}
-	m1Min.Minimize()
-	m2Min := m2.Copy()
-	err = m2Min.Determinize()
+	m2Min, err := m2.Compile().Complete().Minimize(ctx)
 	if err != nil {
 		return false, err
 	}
-	m2Min.Minimize()
 
-	return m1Min.equiv(m2Min), nil
+	return m1Min.equal(m2Min), nil
 }
 
 // GetNewState creates new state that is not yet used in this dfa
@@ -539,6 +441,10 @@ func (m *DFA) GetNewState() State {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	return m.newStateLocked()
+}
+
+func (m *DFA) newStateLocked() State {
 	prefix := "auto_created_"
 	var num int
 	for {
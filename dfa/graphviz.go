@@ -0,0 +1,127 @@
+package dfa
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// GraphVizOptions changes how automaton is drawn by GraphVizWithOptions
+type GraphVizOptions struct {
+	// HideSink omits rejecting states other than the start state whose
+	// transitions all lead back to themselves, such as the state added by
+	// Determinize, together with transitions leading to them
+	HideSink bool
+}
+
+// GraphViz representation string which can be copy-n-pasted into
+// any online tool like http://graphs.grevian.org/graph to get
+// a diagram of the DFA.
+func (m *DFA) GraphViz() string {
+	return m.GraphVizWithOptions(GraphVizOptions{})
+}
+
+// GraphVizWithOptions returns DOT representation of the DFA. Start state is
+// marked with an arrow from an invisible node, final states are drawn with
+// double circles and transitions between the same states are merged into a
+// single edge labeled with comma separated letters, commas and backslashes
+// in letters are escaped with a backslash. States are listed in
+// the order of Compile, so the same automaton is always written the same way
+func (m *DFA) GraphVizWithOptions(opts GraphVizOptions) string {
+	c := m.Compile()
+	n, k := len(c.states), len(c.letters)
+
+	hidden := make([]bool, n)
+	if opts.HideSink {
+		for s := 0; s < n; s++ {
+			hidden[s] = c.IsSink(s)
+		}
+	}
+
+	used := make(map[State]bool, n)
+	for _, s := range c.states {
+		used[s] = true
+	}
+	start := "__start"
+	for used[State(start)] {
+		start += "_"
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("digraph {\n")
+	buf.WriteString("    rankdir=LR;\n")
+	buf.WriteString("    node [shape=circle];\n")
+	fmt.Fprintf(&buf,
+		"    %s [shape=none, label=\"\", width=0, height=0];\n",
+		quoteDOT(start),
+	)
+	fmt.Fprintf(&buf,
+		"    %s -> %s;\n", quoteDOT(start), quoteDOT(string(c.states[c.start])),
+	)
+	for s, name := range c.states {
+		if hidden[s] {
+			continue
+		}
+		if c.final[s] {
+			fmt.Fprintf(&buf,
+				"    %s [shape=doublecircle];\n", quoteDOT(string(name)),
+			)
+		} else {
+			fmt.Fprintf(&buf, "    %s;\n", quoteDOT(string(name)))
+		}
+	}
+
+	for s := 0; s < n; s++ {
+		if hidden[s] {
+			continue
+		}
+		// group letters by target in order of the first letter
+		var targets []int
+		labels := make(map[int][]string)
+		for a := 0; a < k; a++ {
+			to := c.Next(s, a)
+			if to < 0 || hidden[to] {
+				continue
+			}
+			if _, ok := labels[to]; !ok {
+				targets = append(targets, to)
+			}
+			labels[to] = append(labels[to], labelEscaper.Replace(
+				string(c.letters[a]),
+			))
+		}
+		for _, to := range targets {
+			fmt.Fprintf(&buf, "    %s -> %s [label=%s];\n",
+				quoteDOT(string(c.states[s])),
+				quoteDOT(string(c.states[to])),
+				quoteDOT(strings.Join(labels[to], ",")),
+			)
+		}
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
+
+// IsSink checks if s is a rejecting state other than the start state that
+// can not be left
+func (c *Compiled) IsSink(s int) bool {
+	if s == c.start || c.final[s] || len(c.letters) == 0 {
+		return false
+	}
+	for a := range c.letters {
+		if c.Next(s, a) != s {
+			return false
+		}
+	}
+	return true
+}
+
+// labelEscaper escapes letters in comma separated list of letters
+var labelEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`)
+
+// quoteDOT writes s as quoted DOT string
+func quoteDOT(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	return `"` + s + `"`
+}
@@ -0,0 +1,81 @@
+package dfa
+
+// Isomorphism finds for every state of c the state of o that corresponds to
+// it, so that start state, accepting states and transitions are preserved,
+// -1 for states without a pair. Only states reachable from the start state
+// are mapped. If automata are not isomorphic, second return value is false
+// and the result is a partial mapping: states reached with the same word are
+// paired unless they conflict with pairs found before them
+func (c *Compiled) Isomorphism(o *Compiled) ([]int, bool) {
+	forward := make([]int, len(c.states))
+	backward := make([]int, len(o.states))
+	for s := range forward {
+		forward[s] = -1
+	}
+	for t := range backward {
+		backward[t] = -1
+	}
+
+	ok := len(c.letters) == len(o.letters)
+	letters := make([]int, len(c.letters))
+	for a, l := range c.letters {
+		var found bool
+		letters[a], found = o.LetterIndex(l)
+		if !found {
+			letters[a] = -1
+			ok = false
+		}
+	}
+
+	queue := []int{c.start}
+	forward[c.start], backward[o.start] = o.start, c.start
+	if c.final[c.start] != o.final[o.start] {
+		ok = false
+	}
+	for i := 0; i < len(queue); i++ {
+		s := queue[i]
+		t := forward[s]
+		for a := range c.letters {
+			if letters[a] < 0 {
+				continue
+			}
+			sTo, tTo := c.Next(s, a), o.Next(t, letters[a])
+			switch {
+			case sTo < 0 && tTo < 0:
+				continue
+			case sTo < 0 || tTo < 0:
+				ok = false
+				continue
+			case forward[sTo] != -1 || backward[tTo] != -1:
+				if forward[sTo] != tTo {
+					ok = false
+				}
+				continue
+			case c.final[sTo] != o.final[tTo]:
+				ok = false
+				continue
+			}
+			forward[sTo], backward[tTo] = tTo, sTo
+			queue = append(queue, sTo)
+		}
+	}
+	return forward, ok
+}
+
+// Isomorphism finds mapping of states of m1 to states of m2 that preserves
+// start state, accepting states and transitions, so that automata differ
+// only by names of states. Only states reachable from the start state are
+// mapped. If automata are not isomorphic, second return value is false and
+// mapping pairs states reached with the same word that do not conflict with
+// pairs found before them
+func Isomorphism(m1, m2 *DFA) (map[State]State, bool) {
+	c1, c2 := m1.Compile(), m2.Compile()
+	forward, ok := c1.Isomorphism(c2)
+	mapping := make(map[State]State)
+	for s, t := range forward {
+		if t >= 0 {
+			mapping[c1.State(s)] = c2.State(t)
+		}
+	}
+	return mapping, ok
+}
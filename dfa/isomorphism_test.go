@@ -0,0 +1,181 @@
+package dfa
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// renamed copies DFA with states renamed in random order, so its states are
+// also added in different order
+func renamed(r *rand.Rand, m *DFA) (*DFA, map[State]State) {
+	states := m.States()
+	perm := r.Perm(len(states))
+	names := make(map[State]State, len(states))
+	for idx, s := range states {
+		names[s] = State(fmt.Sprint("s", perm[idx]))
+	}
+
+	result := New()
+	for _, l := range m.Alphabet() {
+		result.SetLetter(l)
+	}
+	var finals []State
+	for _, idx := range r.Perm(len(states)) {
+		s := states[idx]
+		result.SetState(names[s])
+		for _, l := range m.Alphabet() {
+			if to, err := m.TransitionTarget(s, l); err == nil {
+				result.SetTransition(names[s], l, names[to]) // nolint: errcheck
+			}
+		}
+		if m.IsFinal(s) {
+			finals = append(finals, names[s])
+		}
+	}
+	result.SetStartState(names[m.StartState()])
+	result.SetFinalStates(finals...)
+	return result, names
+}
+
+// reachable finds states of DFA reachable from the start state
+func reachable(m *DFA) map[State]bool {
+	seen := map[State]bool{m.StartState(): true}
+	queue := []State{m.StartState()}
+	for i := 0; i < len(queue); i++ {
+		for _, l := range m.Alphabet() {
+			to, err := m.TransitionTarget(queue[i], l)
+			if err == nil && !seen[to] {
+				seen[to] = true
+				queue = append(queue, to)
+			}
+		}
+	}
+	return seen
+}
+
+func TestIsomorphism(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	alphabet := []Letter{"a", "b"}
+	for i := 0; i < 100; i++ {
+		m := randomDFA(r, 1+r.Intn(6), alphabet)
+		other, names := renamed(r, m)
+		// unreachable states are ignored
+		other.SetTransition("unreachable", "a", names[m.StartState()]) // nolint: errcheck
+
+		mapping, ok := Isomorphism(m, other)
+		if !ok {
+			t.Fatalf("automaton %d: renamed automaton is not isomorphic", i)
+		}
+		states := reachable(m)
+		if len(mapping) != len(states) {
+			t.Fatalf(
+				"automaton %d: mapping %v, want %d reachable states",
+				i, mapping, len(states),
+			)
+		}
+		// reachable state is determined by a word leading to it, so the
+		// renaming is the only isomorphism
+		for s := range states {
+			if mapping[s] != names[s] {
+				t.Fatalf("automaton %d: mapping %v, want %v", i, mapping, names)
+			}
+		}
+	}
+}
+
+func TestIsomorphismBijection(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	alphabet := []Letter{"a", "b"}
+	for i := 0; i < 200; i++ {
+		m1 := randomDFA(r, 1+r.Intn(4), alphabet)
+		m2 := randomDFA(r, 1+r.Intn(4), alphabet)
+		if r.Intn(2) == 0 {
+			m2, _ = renamed(r, m1)
+		}
+		mapping, ok := Isomorphism(m1, m2)
+
+		// even partial mapping is injective and pairs start states
+		used := make(map[State]bool)
+		for _, t2 := range mapping {
+			if used[t2] {
+				t.Fatalf("pair %d: mapping %v is not injective", i, mapping)
+			}
+			used[t2] = true
+		}
+		if mapping[m1.StartState()] != m2.StartState() {
+			t.Fatalf("pair %d: mapping %v does not keep start state", i, mapping)
+		}
+		if !ok {
+			continue
+		}
+		if len(mapping) != len(reachable(m1)) || len(used) != len(reachable(m2)) {
+			t.Fatalf("pair %d: mapping %v is not a bijection", i, mapping)
+		}
+		for s, t2 := range mapping {
+			if m1.IsFinal(s) != m2.IsFinal(t2) {
+				t.Fatalf("pair %d: mapping %v does not keep final states", i, mapping)
+			}
+			for _, l := range alphabet {
+				to1, err1 := m1.TransitionTarget(s, l)
+				to2, err2 := m2.TransitionTarget(t2, l)
+				if (err1 == nil) != (err2 == nil) || err1 == nil && mapping[to1] != to2 {
+					t.Fatalf("pair %d: mapping %v does not keep transitions", i, mapping)
+				}
+			}
+		}
+	}
+}
+
+func TestNotIsomorphic(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(m *DFA)
+		mapping map[State]State
+	}{
+		{
+			name:    "different final states",
+			change:  func(m *DFA) { m.SetFinalStates("p") },
+			mapping: map[State]State{"p": "p"},
+		},
+		{
+			name: "extra transition",
+			change: func(m *DFA) {
+				m.SetTransition("q", "b", "q") // nolint: errcheck
+			},
+			mapping: map[State]State{"p": "p", "q": "q"},
+		},
+		{
+			name:    "different alphabet",
+			change:  func(m *DFA) { m.SetLetter("c") },
+			mapping: map[State]State{"p": "p", "q": "q"},
+		},
+		{
+			// the same language with one more state
+			name: "not minimal",
+			change: func(m *DFA) {
+				m.SetTransition("q", "a", "r") // nolint: errcheck
+				m.SetTransition("r", "a", "q") // nolint: errcheck
+			},
+			mapping: map[State]State{"p": "p", "q": "q"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other := oddAs()
+			tt.change(other)
+			mapping, ok := Isomorphism(oddAs(), other)
+			if ok {
+				t.Fatal("automata are isomorphic")
+			}
+			if len(mapping) != len(tt.mapping) {
+				t.Fatalf("partial mapping %v, want %v", mapping, tt.mapping)
+			}
+			for s, t2 := range tt.mapping {
+				if mapping[s] != t2 {
+					t.Fatalf("partial mapping %v, want %v", mapping, tt.mapping)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package dfa
+
+import (
+	"context"
+)
+
+// partition is a refinable partition of states 0..n-1. States of every
+// block are kept together in elems, marked states of block b are in
+// elems[first[b]:mid[b]], so marking a state and splitting a block cost time
+// proportional to the number of marked states
+type partition struct {
+	elems []int // states grouped by blocks
+	loc   []int // position of state in elems
+	block []int // block of state
+
+	first, mid, end []int
+
+	touched []int // blocks with marked states
+}
+
+// newPartition creates partition with blocks given by initial block of
+// every state, blocks are numbered from 0 and must not be empty
+func newPartition(initial []int, blocks int) *partition {
+	n := len(initial)
+	p := &partition{
+		elems: make([]int, 0, n),
+		loc:   make([]int, n),
+		block: initial,
+		first: make([]int, blocks),
+		mid:   make([]int, blocks),
+		end:   make([]int, blocks),
+	}
+	for b := 0; b < blocks; b++ {
+		p.first[b] = len(p.elems)
+		for s, sb := range initial {
+			if sb == b {
+				p.loc[s] = len(p.elems)
+				p.elems = append(p.elems, s)
+			}
+		}
+		p.mid[b] = p.first[b]
+		p.end[b] = len(p.elems)
+	}
+	return p
+}
+
+func (p *partition) size(b int) int {
+	return p.end[b] - p.first[b]
+}
+
+func (p *partition) members(b int) []int {
+	return p.elems[p.first[b]:p.end[b]]
+}
+
+func (p *partition) mark(s int) {
+	b := p.block[s]
+	i, j := p.loc[s], p.mid[b]
+	if i < j {
+		return
+	}
+	if j == p.first[b] {
+		p.touched = append(p.touched, b)
+	}
+	p.elems[i], p.elems[j] = p.elems[j], p.elems[i]
+	p.loc[p.elems[i]] = i
+	p.loc[p.elems[j]] = j
+	p.mid[b]++
+}
+
+// split moves marked states of every touched block to a new block, unless
+// all states of the block are marked. Returns pairs of split block and the
+// new block
+func (p *partition) split() [][2]int {
+	var result [][2]int
+	for _, b := range p.touched {
+		if p.mid[b] == p.end[b] {
+			p.mid[b] = p.first[b]
+			continue
+		}
+		nb := len(p.first)
+		p.first = append(p.first, p.first[b])
+		p.mid = append(p.mid, p.first[b])
+		p.end = append(p.end, p.mid[b])
+		p.first[b] = p.mid[b]
+		for _, s := range p.members(nb) {
+			p.block[s] = nb
+		}
+		result = append(result, [2]int{b, nb})
+	}
+	p.touched = p.touched[:0]
+	return result
+}
+
+// hopcroft finds classes of indistinguishable states of complete automaton
+// with n states and k letters using Hopcroft's partition refinement in
+// O(n * k * log n) time. Returned partition has a block for every class
+func hopcroft(
+	ctx context.Context,
+	n, k int,
+	delta []int32,
+	final []bool,
+) (*partition, error) {
+	// inverse[a][t] lists states that go to t with letter a
+	inverse := make([][][]int, k)
+	for a := range inverse {
+		inverse[a] = make([][]int, n)
+		for s := 0; s < n; s++ {
+			to := delta[s*k+a]
+			inverse[a][to] = append(inverse[a][to], s)
+		}
+	}
+
+	initial := make([]int, n)
+	var finals int
+	for s := 0; s < n; s++ {
+		if final[s] {
+			initial[s] = 1
+			finals++
+		}
+	}
+	if finals == 0 || finals == n {
+		for s := range initial {
+			initial[s] = 0
+		}
+		return newPartition(initial, 1), nil
+	}
+	p := newPartition(initial, 2)
+
+	// waiting[b][a] tells if block b is waiting to split others with letter a
+	type splitter struct{ b, a int }
+	var work []splitter
+	waiting := [][]bool{make([]bool, k), make([]bool, k)}
+	smaller := 0
+	if p.size(1) < p.size(0) {
+		smaller = 1
+	}
+	for a := 0; a < k; a++ {
+		work = append(work, splitter{b: smaller, a: a})
+		waiting[smaller][a] = true
+	}
+
+	var preimage []int
+	for len(work) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		sp := work[len(work)-1]
+		work = work[:len(work)-1]
+		waiting[sp.b][sp.a] = false
+
+		preimage = preimage[:0]
+		for _, t := range p.members(sp.b) {
+			preimage = append(preimage, inverse[sp.a][t]...)
+		}
+		for _, s := range preimage {
+			p.mark(s)
+		}
+		for _, pair := range p.split() {
+			b, nb := pair[0], pair[1]
+			waiting = append(waiting, make([]bool, k))
+			for a := 0; a < k; a++ {
+				next := nb
+				if !waiting[b][a] && p.size(b) < p.size(nb) {
+					next = b
+				}
+				work = append(work, splitter{b: next, a: a})
+				waiting[next][a] = true
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// Minimize returns minimal automaton accepting the same language. Its states
+// are numbered in BFS order, each is named after its member that is reached
+// first. Missing transitions are treated as leading to a rejecting sink, they
+// stay missing unless some state of automaton is equivalent to the sink.
+// Error is returned if ctx is done before minimization finishes
+func (c *Compiled) Minimize(ctx context.Context) (*Compiled, error) {
+	k := len(c.letters)
+
+	// number reachable states in BFS order, so the smallest member of a
+	// class is reached first
+	order := []int{c.start}
+	index := make([]int, len(c.states))
+	for s := range index {
+		index[s] = -1
+	}
+	index[c.start] = 0
+	for i := 0; i < len(order); i++ {
+		for a := 0; a < k; a++ {
+			to := c.Next(order[i], a)
+			if to >= 0 && index[to] == -1 {
+				index[to] = len(order)
+				order = append(order, to)
+			}
+		}
+	}
+
+	n := len(order)
+	sink := -1
+	delta := make([]int32, 0, (n+1)*k)
+	final := make([]bool, 0, n+1)
+	for _, s := range order {
+		final = append(final, c.final[s])
+		for a := 0; a < k; a++ {
+			to := c.Next(s, a)
+			if to < 0 {
+				sink = n
+				delta = append(delta, int32(n))
+				continue
+			}
+			delta = append(delta, int32(index[to]))
+		}
+	}
+	size := n
+	if sink >= 0 {
+		size++
+		final = append(final, false)
+		for a := 0; a < k; a++ {
+			delta = append(delta, int32(sink))
+		}
+	}
+
+	p, err := hopcroft(ctx, size, k, delta, final)
+	if err != nil {
+		return nil, err
+	}
+
+	// number classes in BFS order of the quotient automaton
+	blocks := len(p.first)
+	rep := make([]int, blocks)
+	for b := range rep {
+		rep[b] = size
+		for _, s := range p.members(b) {
+			if s < rep[b] {
+				rep[b] = s
+			}
+		}
+	}
+	dropped := -1
+	if sink >= 0 && p.size(p.block[sink]) == 1 {
+		dropped = p.block[sink]
+	}
+	blockIndex := make([]int, blocks)
+	for b := range blockIndex {
+		blockIndex[b] = -1
+	}
+	queue := []int{p.block[0]}
+	blockIndex[p.block[0]] = 0
+	for i := 0; i < len(queue); i++ {
+		for a := 0; a < k; a++ {
+			to := p.block[delta[rep[queue[i]]*k+a]]
+			if to != dropped && blockIndex[to] == -1 {
+				blockIndex[to] = len(queue)
+				queue = append(queue, to)
+			}
+		}
+	}
+
+	result := &Compiled{
+		states:  make([]State, len(queue)),
+		letters: c.letters,
+		delta:   make([]int32, len(queue)*k),
+		final:   make([]bool, len(queue)),
+	}
+	for idx, b := range queue {
+		result.states[idx] = c.states[order[rep[b]]]
+		result.final[idx] = final[rep[b]]
+		for a := 0; a < k; a++ {
+			to := p.block[delta[rep[b]*k+a]]
+			if to == dropped {
+				result.delta[idx*k+a] = -1
+			} else {
+				result.delta[idx*k+a] = int32(blockIndex[to])
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,193 @@
+package dfa
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+)
+
+// words returns all words over alphabet of length at most n
+func words(alphabet []Letter, n int) []Word {
+	result := []Word{{}}
+	last := []Word{{}}
+	for length := 1; length <= n; length++ {
+		var next []Word
+		for _, w := range last {
+			for _, l := range alphabet {
+				next = append(next, append(append(Word{}, w...), l))
+			}
+		}
+		result = append(result, next...)
+		last = next
+	}
+	return result
+}
+
+// sameWords checks that automata agree on all words of length at most n
+func sameWords(t *testing.T, c1, c2 *Compiled, n int) {
+	t.Helper()
+	for _, w := range words(c1.Letters(), n) {
+		if c1.Accepts(w) != c2.Accepts(w) {
+			t.Fatalf("automata disagree on '%s'", w)
+		}
+	}
+}
+
+// nerodeClasses counts classes of reachable states of complete automaton
+// using table filling, independently of Hopcroft's algorithm
+func nerodeClasses(c *Compiled) int {
+	c = c.Complete()
+	walk := map[int]bool{c.Start(): true}
+	queue := []int{c.Start()}
+	for i := 0; i < len(queue); i++ {
+		for a := 0; a < c.NumLetters(); a++ {
+			if to := c.Next(queue[i], a); !walk[to] {
+				walk[to] = true
+				queue = append(queue, to)
+			}
+		}
+	}
+
+	n := c.NumStates()
+	differ := make([][]bool, n)
+	for s := range differ {
+		differ[s] = make([]bool, n)
+		for t := range differ[s] {
+			differ[s][t] = c.IsFinal(s) != c.IsFinal(t)
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, s := range queue {
+			for _, t := range queue {
+				if differ[s][t] {
+					continue
+				}
+				for a := 0; a < c.NumLetters(); a++ {
+					if differ[c.Next(s, a)][c.Next(t, a)] {
+						differ[s][t], changed = true, true
+						break
+					}
+				}
+			}
+		}
+	}
+
+	classes := 0
+	for i, s := range queue {
+		first := true
+		for _, t := range queue[:i] {
+			if !differ[s][t] {
+				first = false
+				break
+			}
+		}
+		if first {
+			classes++
+		}
+	}
+	return classes
+}
+
+func TestMinimizeRegex(t *testing.T) {
+	ab := []Letter{"a", "b"}
+	tests := []struct {
+		pattern string
+		states  int
+	}{
+		{"[]", 1},
+		{"()", 2},
+		{"(a|b)*", 1},
+		{"a*", 2},
+		{"(aa)*", 3},
+		{"((a|b)(a|b))*", 2},
+		{"(a|b)*a", 2},
+		{"(a|b)*a(a|b)", 4},
+		{"(a|b)*a(a|b)(a|b)", 8},
+		{"(b|ab*ab*a)*", 3},
+		{"(a|b)*abb(a|b)*", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			m, err := CompileRegex(tt.pattern, ab)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := m.Compile()
+			min, err := c.Minimize(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if min.NumStates() != tt.states {
+				t.Errorf(
+					"minimized automaton has %d states, want %d",
+					min.NumStates(), tt.states,
+				)
+			}
+			sameWords(t, c, min, 8)
+
+			again, err := min.Minimize(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !again.equal(min) {
+				t.Error("minimizing minimal automaton changed it")
+			}
+		})
+	}
+}
+
+func TestMinimizeMissingTransitions(t *testing.T) {
+	m := New()
+	m.SetTransition("p", "a", "q")
+	m.SetTransition("q", "a", "r")
+	m.SetTransition("r", "a", "q")
+	m.SetLetter("b")
+	m.SetStartState("p")
+	m.SetFinalStates("q", "r")
+
+	min, err := m.Compile().Minimize(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// q and r are equivalent and no state is equivalent to the sink, so
+	// transitions with b stay missing
+	if min.NumStates() != 2 {
+		t.Fatalf("minimized automaton has %d states, want 2", min.NumStates())
+	}
+	for s := 0; s < min.NumStates(); s++ {
+		if b, _ := min.LetterIndex("b"); min.Next(s, b) >= 0 {
+			t.Errorf("state %s got transition with b", min.State(s))
+		}
+	}
+	sameWords(t, m.Compile(), min, 6)
+}
+
+func TestMinimizeRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	alphabets := [][]Letter{{"a"}, {"a", "b"}, {"a", "b", "c"}}
+	for i := 0; i < 300; i++ {
+		alphabet := alphabets[i%len(alphabets)]
+		c := randomDFA(r, 1+r.Intn(8), alphabet).Compile()
+		min, err := c.Complete().Minimize(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := nerodeClasses(c); min.NumStates() != want {
+			t.Fatalf(
+				"automaton %d: minimized has %d states, want %d",
+				i, min.NumStates(), want,
+			)
+		}
+		sameWords(t, c, min, 6)
+	}
+}
+
+func TestMinimizeCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := randomDFA(rand.New(rand.NewSource(2)), 50, []Letter{"a", "b"}).Compile()
+	if _, err := c.Minimize(ctx); err == nil {
+		t.Error("minimization did not stop after cancellation")
+	}
+}
@@ -0,0 +1,266 @@
+package dfa
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// NFA describes a nondeterministic automaton with epsilon moves. It exists
+// so that nondeterministic solutions can be converted into a DFA and graded
+// the same way as deterministic ones
+type NFA struct {
+	q   map[State]bool                   // States
+	e   map[Letter]bool                  // Alphabet
+	d   map[domainElement]map[State]bool // Transition
+	eps map[State]map[State]bool         // Epsilon transitions
+	q0  State                            // Start State
+	f   map[State]bool                   // Final States
+
+	mu *sync.Mutex
+}
+
+// NewNFA creates empty NFA
+func NewNFA() *NFA {
+	return &NFA{
+		q:   make(map[State]bool),
+		e:   make(map[Letter]bool),
+		d:   make(map[domainElement]map[State]bool),
+		eps: make(map[State]map[State]bool),
+		f:   make(map[State]bool),
+		mu:  &sync.Mutex{},
+	}
+}
+
+// SetTransition adds new transition to NFA, existing transitions on the same
+// input are kept
+func (m *NFA) SetTransition(from State, input Letter, to State) error {
+	if from == State("") || to == State("") {
+		return errors.New("state cannot be defined as the empty string")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.q[to] = true
+	m.q[from] = true
+	m.e[input] = true
+	de := domainElement{l: input, s: from}
+	if m.d[de] == nil {
+		m.d[de] = make(map[State]bool)
+	}
+	m.d[de][to] = true
+
+	return nil
+}
+
+// SetEpsilonTransition adds new transition that does not consume input
+func (m *NFA) SetEpsilonTransition(from, to State) error {
+	if from == State("") || to == State("") {
+		return errors.New("state cannot be defined as the empty string")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.q[to] = true
+	m.q[from] = true
+	if m.eps[from] == nil {
+		m.eps[from] = make(map[State]bool)
+	}
+	m.eps[from][to] = true
+
+	return nil
+}
+
+// SetLetter adds a new symbol to alphabet
+func (m *NFA) SetLetter(l Letter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.e[l] = true
+}
+
+// SetState adds a new state to list of states
+func (m *NFA) SetState(q State) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.q[q] = true
+}
+
+// SetStartState sets q0, there can be only one.
+func (m *NFA) SetStartState(q0 State) {
+	m.q0 = q0
+}
+
+// SetFinalStates marks final states, there can be more than one.
+// Calling this function multiple times overrides previously set final states
+func (m *NFA) SetFinalStates(f ...State) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.f = make(map[State]bool)
+	for _, q := range f {
+		m.f[q] = true
+	}
+}
+
+// HasState checks if NFA has given state
+func (m *NFA) HasState(s State) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.q[s]
+}
+
+// HasLetter checks if NFA has given letter
+func (m *NFA) HasLetter(l Letter) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.e[l]
+}
+
+// Valid checks if start state exists and is within set of NFA's states. Also
+// checks if all final states are within set of NFA's states
+func (m *NFA) Valid() (bool, error) {
+	if m.q0 == State("") {
+		return false, errors.New("no start state defined")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.q[m.q0] {
+		return false,
+			fmt.Errorf("start state '%v' is not in the set of states", m.q0)
+	}
+	for s := range m.f {
+		if !m.q[s] {
+			return false,
+				fmt.Errorf("final state '%v' is not in the set of states", s)
+		}
+	}
+
+	return true, nil
+}
+
+// closure returns all states reachable from given states using only epsilon
+// transitions, caller must hold the lock
+func (m *NFA) closure(states map[State]bool) map[State]bool {
+	result := make(map[State]bool, len(states))
+	stack := make([]State, 0, len(states))
+	for s := range states {
+		result[s] = true
+		stack = append(stack, s)
+	}
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for to := range m.eps[s] {
+			if !result[to] {
+				result[to] = true
+				stack = append(stack, to)
+			}
+		}
+	}
+	return result
+}
+
+// subsetNames gives names to DFA states after the sets of NFA states they
+// represent, e.g. "{q0,q1}". Names of NFA states may contain commas, so
+// different sets may get the same name, such names get a suffix
+type subsetNames struct {
+	names map[string]State
+	used  map[State]bool
+}
+
+func newSubsetNames() *subsetNames {
+	return &subsetNames{
+		names: make(map[string]State),
+		used:  make(map[State]bool),
+	}
+}
+
+// name returns name of the set of states and whether it was seen before
+func (n *subsetNames) name(states map[State]bool) (State, bool) {
+	names := make([]string, 0, len(states))
+	for s := range states {
+		names = append(names, string(s))
+	}
+	sort.Strings(names)
+	quoted := make([]string, len(names))
+	for i, s := range names {
+		quoted[i] = strconv.Quote(s)
+	}
+	key := strings.Join(quoted, ",")
+	if name, ok := n.names[key]; ok {
+		return name, true
+	}
+
+	name := State("{" + strings.Join(names, ",") + "}")
+	for n.used[name] {
+		name += "'"
+	}
+	n.used[name] = true
+	n.names[key] = name
+	return name, false
+}
+
+// ToDFA converts NFA into an equivalent complete DFA using subset
+// construction. Only subsets reachable from the start state are created and
+// each DFA state is named after the NFA states it contains, e.g. "{q0,q1}",
+// with "'" appended when names of different subsets would be the same
+func (m *NFA) ToDFA() (*DFA, error) {
+	valid, err := m.Valid()
+	if !valid {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := New()
+	for l := range m.e {
+		result.SetLetter(l)
+	}
+
+	names := newSubsetNames()
+	start := m.closure(map[State]bool{m.q0: true})
+	startName, _ := names.name(start)
+	result.SetState(startName)
+	result.SetStartState(startName)
+
+	var finals []State
+	subsets := map[State]map[State]bool{startName: start}
+	queue := []State{startName}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		subset := subsets[name]
+
+		for s := range subset {
+			if m.f[s] {
+				finals = append(finals, name)
+				break
+			}
+		}
+
+		for l := range m.e {
+			reached := make(map[State]bool)
+			for s := range subset {
+				for to := range m.d[domainElement{l: l, s: s}] {
+					reached[to] = true
+				}
+			}
+			reached = m.closure(reached)
+			to, seen := names.name(reached)
+			if !seen {
+				subsets[to] = reached
+				queue = append(queue, to)
+			}
+			err := result.SetTransition(name, l, to)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	result.SetFinalStates(finals...)
+
+	return result, nil
+}
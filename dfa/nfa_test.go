@@ -0,0 +1,135 @@
+package dfa
+
+import (
+	"context"
+	"testing"
+)
+
+// accepts follows transitions of DFA on letters, missing transition rejects
+func accepts(m *DFA, letters ...Letter) bool {
+	s := m.StartState()
+	for _, l := range letters {
+		to, err := m.TransitionTarget(s, l)
+		if err != nil {
+			return false
+		}
+		s = to
+	}
+	return m.IsFinal(s)
+}
+
+func TestNFAClosure(t *testing.T) {
+	m := NewNFA()
+	m.SetEpsilonTransition("p", "q")
+	m.SetEpsilonTransition("q", "r")
+	m.SetEpsilonTransition("r", "p")
+	m.SetEpsilonTransition("s", "p")
+	m.SetTransition("p", "a", "s")
+
+	tests := []struct {
+		from []State
+		want []State
+	}{
+		{[]State{"p"}, []State{"p", "q", "r"}},
+		{[]State{"r"}, []State{"p", "q", "r"}},
+		{[]State{"s"}, []State{"p", "q", "r", "s"}},
+		{[]State{}, []State{}},
+	}
+	for _, tt := range tests {
+		from := make(map[State]bool)
+		for _, s := range tt.from {
+			from[s] = true
+		}
+		got := m.closure(from)
+		if len(got) != len(tt.want) {
+			t.Errorf("closure of %v is %v, want %v", tt.from, got, tt.want)
+			continue
+		}
+		for _, s := range tt.want {
+			if !got[s] {
+				t.Errorf("closure of %v is %v, want %v", tt.from, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestNFAToDFA(t *testing.T) {
+	// words over {a, b} with a as the second letter from the end, the
+	// epsilon transition lets the word start at p or q
+	n := NewNFA()
+	n.SetTransition("s", "a", "s")
+	n.SetTransition("s", "b", "s")
+	n.SetEpsilonTransition("s", "p")
+	n.SetTransition("p", "a", "q")
+	n.SetTransition("q", "a", "r")
+	n.SetTransition("q", "b", "r")
+	n.SetStartState("s")
+	n.SetFinalStates("r")
+
+	want := New()
+	for _, tr := range []struct {
+		from  State
+		input Letter
+		to    State
+	}{
+		{"bb", "a", "ba"}, {"bb", "b", "bb"},
+		{"ba", "a", "aa"}, {"ba", "b", "ab"},
+		{"aa", "a", "aa"}, {"aa", "b", "ab"},
+		{"ab", "a", "ba"}, {"ab", "b", "bb"},
+	} {
+		want.SetTransition(tr.from, tr.input, tr.to)
+	}
+	want.SetStartState("bb")
+	want.SetFinalStates("aa", "ab")
+
+	m, err := n.ToDFA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.StartState() != "{p,s}" {
+		t.Errorf("start state is %s, want {p,s}", m.StartState())
+	}
+	if len(m.States()) != 4 {
+		t.Errorf("DFA has states %v, want 4 subsets", m.States())
+	}
+	if eq, err := Compare(context.Background(), m, want); err != nil || !eq {
+		t.Errorf("DFA is not equivalent to expected one: %v", err)
+	}
+}
+
+func TestNFAToDFANames(t *testing.T) {
+	// names of states contain commas and braces like names of subsets
+	n := NewNFA()
+	n.SetTransition("p", "a", "x,y")
+	n.SetTransition("p", "b", "x")
+	n.SetTransition("p", "b", "y")
+	n.SetTransition("p", "c", "{x}")
+	n.SetTransition("x,y", "a", "x,y")
+	n.SetStartState("p")
+	n.SetFinalStates("x,y")
+
+	m, err := n.ToDFA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		word     []Letter
+		accepted bool
+	}{
+		{[]Letter{"a"}, true},
+		{[]Letter{"a", "a"}, true},
+		{[]Letter{"b"}, false},
+		{[]Letter{"b", "a"}, false},
+		{[]Letter{"c"}, false},
+	}
+	for _, tt := range tests {
+		if accepts(m, tt.word...) != tt.accepted {
+			t.Errorf("%v accepted is %v", tt.word, !tt.accepted)
+		}
+	}
+	// {x,y}, {"x,y"}, {"{x}"}, {} and the start state
+	if len(m.States()) != 5 {
+		t.Errorf("DFA has states %v, want 5", m.States())
+	}
+}
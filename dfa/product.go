@@ -0,0 +1,270 @@
+package dfa
+
+import (
+	"math/big"
+	"sort"
+)
+
+// Product is a synchronous product of two automata over the union of their
+// alphabets. Pairs of states are numbered in the order they are reached from
+// the start pair, so the start pair has index 0. Missing transitions lead to
+// implicit dead state, which is -1 in pairs
+type Product struct {
+	letters []Letter
+	pairs   [][2]int
+	delta   [][]int
+	final1  []bool
+	final2  []bool
+}
+
+// NewProduct builds product of automata, only pairs reachable from the start
+// pair are created
+func NewProduct(m1, m2 *DFA) *Product {
+	return NewCompiledProduct(m1.Compile(), m2.Compile())
+}
+
+// NewCompiledProduct builds product of compiled automata, only pairs
+// reachable from the start pair are created
+func NewCompiledProduct(c1, c2 *Compiled) *Product {
+	p := &Product{letters: make([]Letter, 0, len(c1.letters)+len(c2.letters))}
+	p.letters = append(p.letters, c1.letters...)
+	for _, l := range c2.letters {
+		if _, ok := c1.LetterIndex(l); !ok {
+			p.letters = append(p.letters, l)
+		}
+	}
+	sort.Slice(p.letters, func(i, j int) bool {
+		return p.letters[i] < p.letters[j]
+	})
+
+	// letter indexes in both automata, -1 if automaton lacks the letter
+	idx1 := make([]int, len(p.letters))
+	idx2 := make([]int, len(p.letters))
+	for i, l := range p.letters {
+		idx1[i], idx2[i] = -1, -1
+		if a, ok := c1.LetterIndex(l); ok {
+			idx1[i] = a
+		}
+		if a, ok := c2.LetterIndex(l); ok {
+			idx2[i] = a
+		}
+	}
+	step := func(c *Compiled, s, a int) int {
+		if s < 0 || a < 0 {
+			return -1
+		}
+		return c.Next(s, a)
+	}
+
+	index := make(map[[2]int]int)
+	add := func(pair [2]int) int {
+		if idx, ok := index[pair]; ok {
+			return idx
+		}
+		idx := len(p.pairs)
+		index[pair] = idx
+		p.pairs = append(p.pairs, pair)
+		p.final1 = append(p.final1, pair[0] >= 0 && c1.final[pair[0]])
+		p.final2 = append(p.final2, pair[1] >= 0 && c2.final[pair[1]])
+		return idx
+	}
+
+	add([2]int{c1.start, c2.start})
+	for idx := 0; idx < len(p.pairs); idx++ {
+		pair := p.pairs[idx]
+		next := make([]int, len(p.letters))
+		for i := range p.letters {
+			next[i] = add([2]int{
+				step(c1, pair[0], idx1[i]),
+				step(c2, pair[1], idx2[i]),
+			})
+		}
+		p.delta = append(p.delta, next)
+	}
+
+	return p
+}
+
+// Size returns number of pairs in product
+func (p *Product) Size() int {
+	return len(p.pairs)
+}
+
+// Successors returns pairs reached from pair idx, one for each letter of
+// the alphabet in sorted order
+func (p *Product) Successors(idx int) []int {
+	return p.delta[idx]
+}
+
+// Differs checks if exactly one of automata accepts in pair idx
+func (p *Product) Differs(idx int) bool {
+	return p.final1[idx] != p.final2[idx]
+}
+
+// DistinguishingWord finds the shortest word that is accepted by exactly one
+// of the automata, among words of the same length the lexicographically
+// smallest is returned. Second return value is false if automata accept the
+// same language
+func DistinguishingWord(m1, m2 *DFA) (Word, bool) {
+	return m1.Compile().DistinguishingWord(m2.Compile())
+}
+
+// DistinguishingWords returns up to n shortest words that are accepted by
+// exactly one of the automata, ordered by length and then lexicographically
+func DistinguishingWords(m1, m2 *DFA, n int) []Word {
+	return m1.Compile().DistinguishingWords(m2.Compile(), n)
+}
+
+// DistinguishingWord is DistinguishingWord for compiled automata
+func (c *Compiled) DistinguishingWord(o *Compiled) (Word, bool) {
+	words := c.DistinguishingWords(o, 1)
+	if len(words) == 0 {
+		return nil, false
+	}
+	return words[0], true
+}
+
+// DistinguishingWords is DistinguishingWords for compiled automata
+func (c *Compiled) DistinguishingWords(o *Compiled, n int) []Word {
+	p := NewCompiledProduct(c, o)
+
+	// reach[k][idx] tells if a differing pair is reachable from pair idx
+	// with a word of exactly length k
+	reach := [][]bool{make([]bool, len(p.pairs))}
+	for idx := range p.pairs {
+		reach[0][idx] = p.Differs(idx)
+	}
+	extend := func() {
+		prev := reach[len(reach)-1]
+		next := make([]bool, len(p.pairs))
+		for idx := range p.pairs {
+			for _, to := range p.delta[idx] {
+				if prev[to] {
+					next[idx] = true
+					break
+				}
+			}
+		}
+		reach = append(reach, next)
+	}
+
+	var words []Word
+	// if a longer word exists, one also exists within the next len(pairs)
+	// lengths, so search can stop after that many lengths without results
+	lastFound := 0
+	for length := 0; len(words) < n && length-lastFound <= len(p.pairs); length++ {
+		for len(reach) <= length {
+			extend()
+		}
+		if !reach[length][0] {
+			continue
+		}
+		lastFound = length
+		words = p.collect(reach, 0, length, nil, words, n)
+	}
+
+	return words
+}
+
+// collect appends to words all words of given length that lead from pair idx
+// to a differing pair, in lexicographical order, until there are n words
+func (p *Product) collect(
+	reach [][]bool,
+	idx, length int,
+	prefix Word,
+	words []Word,
+	n int,
+) []Word {
+	if length == 0 {
+		word := make(Word, len(prefix))
+		copy(word, prefix)
+		return append(words, word)
+	}
+	for i, to := range p.delta[idx] {
+		if len(words) >= n {
+			break
+		}
+		if reach[length-1][to] {
+			words = p.collect(
+				reach, to, length-1, append(prefix, p.letters[i]), words, n,
+			)
+		}
+	}
+	return words
+}
+
+// WordCounter counts words of increasing length on product of two automata.
+// For each length it keeps number of words leading to every pair of states,
+// so counting up to length n takes O(n * pairs * alphabet) additions
+type WordCounter struct {
+	p      *Product
+	counts []*big.Int
+	length int
+}
+
+// NewWordCounter creates counter positioned at words of length 0
+func NewWordCounter(m1, m2 *DFA) *WordCounter {
+	p := NewProduct(m1, m2)
+	counts := make([]*big.Int, len(p.pairs))
+	for idx := range counts {
+		counts[idx] = new(big.Int)
+	}
+	counts[0].SetInt64(1)
+	return &WordCounter{p: p, counts: counts}
+}
+
+// Length returns length of words currently counted
+func (c *WordCounter) Length() int {
+	return c.length
+}
+
+// Differing returns number of words of current length that are accepted by
+// exactly one of the automata
+func (c *WordCounter) Differing() *big.Int {
+	result := new(big.Int)
+	for idx, count := range c.counts {
+		if c.p.Differs(idx) {
+			result.Add(result, count)
+		}
+	}
+	return result
+}
+
+// AcceptedBySecond returns number of words of current length that are
+// accepted by the second automaton
+func (c *WordCounter) AcceptedBySecond() *big.Int {
+	result := new(big.Int)
+	for idx, count := range c.counts {
+		if c.p.final2[idx] {
+			result.Add(result, count)
+		}
+	}
+	return result
+}
+
+// Words returns number of all words of current length
+func (c *WordCounter) Words() *big.Int {
+	result := new(big.Int)
+	for _, count := range c.counts {
+		result.Add(result, count)
+	}
+	return result
+}
+
+// Next moves counter to words that are one letter longer
+func (c *WordCounter) Next() {
+	next := make([]*big.Int, len(c.counts))
+	for idx := range next {
+		next[idx] = new(big.Int)
+	}
+	for idx, count := range c.counts {
+		if count.Sign() == 0 {
+			continue
+		}
+		for _, to := range c.p.delta[idx] {
+			next[to].Add(next[to], count)
+		}
+	}
+	c.counts = next
+	c.length++
+}
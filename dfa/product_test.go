@@ -0,0 +1,127 @@
+package dfa
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// wordsOfLength returns all words of exactly length n in lexicographical
+// order
+func wordsOfLength(alphabet []Letter, n int) [][]Letter {
+	result := [][]Letter{{}}
+	for length := 0; length < n; length++ {
+		var next [][]Letter
+		for _, w := range result {
+			for _, l := range alphabet {
+				next = append(next, append(append([]Letter{}, w...), l))
+			}
+		}
+		result = next
+	}
+	return result
+}
+
+// differing finds by enumeration up to n shortest words of length at most
+// maxLength that are accepted by exactly one of automata
+func differing(m1, m2 *DFA, alphabet []Letter, n, maxLength int) [][]Letter {
+	var result [][]Letter
+	for length := 0; length <= maxLength; length++ {
+		for _, w := range wordsOfLength(alphabet, length) {
+			if len(result) < n && accepts(m1, w...) != accepts(m2, w...) {
+				result = append(result, w)
+			}
+		}
+	}
+	return result
+}
+
+func TestDistinguishingWords(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	alphabet := []Letter{"a", "b"}
+	for i := 0; i < 200; i++ {
+		m1 := randomDFA(r, 1+r.Intn(4), alphabet)
+		m2 := randomDFA(r, 1+r.Intn(4), alphabet)
+		// automata with at most 4 states and a sink that differ, differ on
+		// a word of at most 8 letters
+		want := differing(m1, m2, alphabet, 5, 8)
+		got := DistinguishingWords(m1, m2, 5)
+		if len(got) != len(want) {
+			t.Fatalf("got words %v, want %v", got, want)
+		}
+		for idx := range got {
+			if !reflect.DeepEqual([]Letter(got[idx]), want[idx]) {
+				t.Fatalf("got words %v, want %v", got, want)
+			}
+		}
+
+		word, ok := DistinguishingWord(m1, m2)
+		if ok != (len(want) > 0) ||
+			ok && !reflect.DeepEqual([]Letter(word), want[0]) {
+			t.Fatalf("got word %v, %v, want %v", word, ok, want)
+		}
+	}
+}
+
+func TestWordCounter(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		// the second automaton may lack some letters
+		alphabet := []Letter{"a", "b", "c"}
+		m1 := randomDFA(r, 1+r.Intn(4), alphabet)
+		m2 := randomDFA(r, 1+r.Intn(4), alphabet[:1+r.Intn(3)])
+
+		counter := NewWordCounter(m1, m2)
+		for length := 0; length <= 6; length++ {
+			var diff, second int64
+			all := wordsOfLength(alphabet, length)
+			for _, w := range all {
+				if accepts(m1, w...) != accepts(m2, w...) {
+					diff++
+				}
+				if accepts(m2, w...) {
+					second++
+				}
+			}
+			if counter.Length() != length ||
+				counter.Differing().Int64() != diff ||
+				counter.AcceptedBySecond().Int64() != second ||
+				counter.Words().Int64() != int64(len(all)) {
+				t.Fatalf(
+					"length %d: got %d, %d, %d words, want %d, %d, %d",
+					length, counter.Differing(), counter.AcceptedBySecond(),
+					counter.Words(), diff, second, len(all),
+				)
+			}
+			counter.Next()
+		}
+	}
+}
+
+func TestWordCounterLarge(t *testing.T) {
+	// all words over {a, b} and words with even number of a's
+	m1, m2 := New(), New()
+	m1.SetTransition("p", "a", "p")
+	m1.SetTransition("p", "b", "p")
+	m1.SetStartState("p")
+	m1.SetFinalStates("p")
+	m2.SetTransition("e", "a", "o")
+	m2.SetTransition("e", "b", "e")
+	m2.SetTransition("o", "a", "e")
+	m2.SetTransition("o", "b", "o")
+	m2.SetStartState("e")
+	m2.SetFinalStates("e")
+
+	counter := NewWordCounter(m1, m2)
+	for counter.Length() < 100 {
+		counter.Next()
+	}
+	// half of 2^100 words have even number of a's
+	if counter.Words().BitLen() != 101 ||
+		counter.Differing().Cmp(counter.AcceptedBySecond()) != 0 ||
+		counter.Differing().BitLen() != 100 {
+		t.Errorf(
+			"got %v words, %v differing", counter.Words(), counter.Differing(),
+		)
+	}
+}
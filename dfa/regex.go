@@ -0,0 +1,643 @@
+package dfa
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type regexKind int
+
+const (
+	regexEmpty   regexKind = iota // matches nothing
+	regexEpsilon                  // matches empty word
+	regexLetter
+	regexUnion
+	regexConcat
+	regexStar
+)
+
+// regex is a node of regular expression syntax tree
+type regex struct {
+	kind regexKind
+	l    Letter
+	subs []*regex
+	size int // number of letters in expression
+}
+
+func newLetterRegex(l Letter) *regex {
+	return &regex{kind: regexLetter, l: l, size: 1}
+}
+
+// sumSize returns number of letters in all expressions
+func sumSize(subs []*regex) int {
+	var size int
+	for _, sub := range subs {
+		size += sub.size
+	}
+	return size
+}
+
+// newUnionRegex creates union of given expressions, nested unions are
+// flattened, duplicates and empty languages are removed
+func newUnionRegex(subs ...*regex) *regex {
+	var flat []*regex
+	seen := make(map[string]bool)
+	var hasEpsilon, hasNullable bool
+	for _, sub := range subs {
+		parts := []*regex{sub}
+		if sub.kind == regexUnion {
+			parts = sub.subs
+		}
+		for _, part := range parts {
+			switch part.kind {
+			case regexEmpty:
+				continue
+			case regexEpsilon:
+				hasEpsilon = true
+				continue
+			}
+			key := part.String()
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			hasNullable = hasNullable || part.nullable()
+			flat = append(flat, part)
+		}
+	}
+	if hasEpsilon {
+		// x x* or empty word is x*
+		for idx, part := range flat {
+			if part.kind == regexConcat && len(part.subs) == 2 &&
+				part.subs[1].kind == regexStar &&
+				part.subs[1].subs[0].String() == part.subs[0].String() {
+				flat[idx] = part.subs[1]
+				hasNullable = true
+			}
+		}
+	}
+	sort.Slice(flat, func(i, j int) bool {
+		return flat[i].String() < flat[j].String()
+	})
+	if hasEpsilon && !hasNullable {
+		flat = append([]*regex{{kind: regexEpsilon}}, flat...)
+	}
+
+	if len(flat) == 0 {
+		if hasEpsilon {
+			return &regex{kind: regexEpsilon}
+		}
+		return &regex{kind: regexEmpty}
+	}
+	if len(flat) == 1 {
+		return flat[0]
+	}
+	return &regex{kind: regexUnion, subs: flat, size: sumSize(flat)}
+}
+
+// newConcatRegex creates concatenation of given expressions, nested
+// concatenations are flattened and empty words are removed
+func newConcatRegex(subs ...*regex) *regex {
+	var flat []*regex
+	for _, sub := range subs {
+		switch sub.kind {
+		case regexEmpty:
+			return sub
+		case regexEpsilon:
+			continue
+		case regexConcat:
+			flat = append(flat, sub.subs...)
+		default:
+			flat = append(flat, sub)
+		}
+	}
+
+	if len(flat) == 0 {
+		return &regex{kind: regexEpsilon}
+	}
+	if len(flat) == 1 {
+		return flat[0]
+	}
+	return &regex{kind: regexConcat, subs: flat, size: sumSize(flat)}
+}
+
+func newStarRegex(sub *regex) *regex {
+	switch sub.kind {
+	case regexEmpty, regexEpsilon:
+		return &regex{kind: regexEpsilon}
+	case regexStar:
+		return sub
+	case regexUnion:
+		if sub.subs[0].kind == regexEpsilon {
+			return newStarRegex(newUnionRegex(sub.subs[1:]...))
+		}
+	}
+	return &regex{kind: regexStar, subs: []*regex{sub}, size: sub.size}
+}
+
+// nullable checks if expression matches empty word
+func (r *regex) nullable() bool {
+	switch r.kind {
+	case regexEpsilon, regexStar:
+		return true
+	case regexUnion:
+		for _, sub := range r.subs {
+			if sub.nullable() {
+				return true
+			}
+		}
+	case regexConcat:
+		for _, sub := range r.subs {
+			if !sub.nullable() {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func (r *regex) String() string {
+	return r.format(regexPrecUnion)
+}
+
+const (
+	regexPrecUnion = iota
+	regexPrecConcat
+	regexPrecStar
+)
+
+// format writes expression in syntax accepted by regexParser, expression is
+// put in parentheses if its precedence is lower than prec
+func (r *regex) format(prec int) string {
+	switch r.kind {
+	case regexEmpty:
+		return "[]"
+	case regexEpsilon:
+		return "()"
+	case regexLetter:
+		l := string(r.l)
+		if strings.ContainsRune(regexMeta, []rune(l)[0]) {
+			l = `\` + l
+		}
+		// letters of several characters are put in parentheses when they
+		// are repeated or concatenated, so that parser does not read them
+		// together with the following letters, e.g. x(yz) is not xy z
+		return parenthesize(
+			l,
+			prec == regexPrecStar && utf8.RuneCountInString(string(r.l)) > 1,
+		)
+	case regexStar:
+		return r.subs[0].format(regexPrecStar) + "*"
+	case regexUnion:
+		if r.subs[0].kind == regexEpsilon {
+			return newUnionRegex(r.subs[1:]...).format(regexPrecStar) + "?"
+		}
+		parts := make([]string, 0, len(r.subs))
+		for _, sub := range r.subs {
+			parts = append(parts, sub.format(regexPrecConcat))
+		}
+		return parenthesize(strings.Join(parts, "|"), prec > regexPrecUnion)
+	case regexConcat:
+		var buf bytes.Buffer
+		for i := 0; i < len(r.subs); i++ {
+			sub := r.subs[i]
+			// x x* is written as x+
+			if i+1 < len(r.subs) && r.subs[i+1].kind == regexStar &&
+				r.subs[i+1].subs[0].String() == sub.String() {
+				buf.WriteString(sub.format(regexPrecStar) + "+")
+				i++
+				continue
+			}
+			if sub.kind == regexLetter {
+				buf.WriteString(sub.format(regexPrecStar))
+				continue
+			}
+			buf.WriteString(sub.format(regexPrecConcat))
+		}
+		return parenthesize(buf.String(), prec > regexPrecConcat)
+	}
+	return ""
+}
+
+func parenthesize(s string, needed bool) string {
+	if needed {
+		return "(" + s + ")"
+	}
+	return s
+}
+
+// regexParser is a recursive descent parser for regular expressions over
+// alphabet of the automaton. Supported syntax is union "|", concatenation,
+// Kleene star "*", plus "+", optional "?", groups "( )", any letter "." and
+// character classes "[abc]", "[a-c]", "[^ab]". Metacharacters are escaped
+// with "\"
+type regexParser struct {
+	pattern  string
+	pos      int
+	alphabet []Letter
+	letters  map[Letter]bool
+}
+
+const regexMeta = `|*+?()[].\`
+
+func parseRegex(pattern string, alphabet []Letter) (*regex, error) {
+	p := &regexParser{
+		pattern:  pattern,
+		alphabet: alphabet,
+		letters:  make(map[Letter]bool),
+	}
+	for _, l := range alphabet {
+		p.letters[l] = true
+	}
+
+	r, err := p.parseUnion()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, p.errorf("unexpected '%c'", p.peek())
+	}
+	return r, nil
+}
+
+func (p *regexParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf(
+		"regex: %s at position %d", fmt.Sprintf(format, args...), p.pos,
+	)
+}
+
+func (p *regexParser) done() bool {
+	return p.pos >= len(p.pattern)
+}
+
+func (p *regexParser) peek() rune {
+	r, _ := utf8.DecodeRuneInString(p.pattern[p.pos:])
+	return r
+}
+
+func (p *regexParser) next() rune {
+	r, size := utf8.DecodeRuneInString(p.pattern[p.pos:])
+	p.pos += size
+	return r
+}
+
+func (p *regexParser) parseUnion() (*regex, error) {
+	var subs []*regex
+	for {
+		r, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, r)
+		if p.done() || p.peek() != '|' {
+			break
+		}
+		p.next()
+	}
+	return newUnionRegex(subs...), nil
+}
+
+func (p *regexParser) parseConcat() (*regex, error) {
+	var subs []*regex
+	for !p.done() && p.peek() != '|' && p.peek() != ')' {
+		r, err := p.parseRepeat()
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, r)
+	}
+	return newConcatRegex(subs...), nil
+}
+
+func (p *regexParser) parseRepeat() (*regex, error) {
+	r, err := p.parseAtom()
+	if err != nil {
+		return nil, err
+	}
+	for !p.done() {
+		switch p.peek() {
+		case '*':
+			r = newStarRegex(r)
+		case '+':
+			r = newConcatRegex(r, newStarRegex(r))
+		case '?':
+			r = newUnionRegex(r, newConcatRegex())
+		default:
+			return r, nil
+		}
+		p.next()
+	}
+	return r, nil
+}
+
+func (p *regexParser) parseAtom() (*regex, error) {
+	switch c := p.peek(); c {
+	case '(':
+		p.next()
+		r, err := p.parseUnion()
+		if err != nil {
+			return nil, err
+		}
+		if p.done() || p.next() != ')' {
+			return nil, p.errorf("missing ')'")
+		}
+		return r, nil
+	case '[':
+		p.next()
+		return p.parseClass()
+	case '.':
+		p.next()
+		subs := make([]*regex, 0, len(p.alphabet))
+		for _, l := range p.alphabet {
+			subs = append(subs, newLetterRegex(l))
+		}
+		return newUnionRegex(subs...), nil
+	case '*', '+', '?':
+		return nil, p.errorf("nothing to repeat before '%c'", c)
+	case ']':
+		return nil, p.errorf("unexpected '%c'", c)
+	}
+	l, err := p.parseLetter()
+	if err != nil {
+		return nil, err
+	}
+	return newLetterRegex(l), nil
+}
+
+// parseLetter reads the longest alphabet letter that starts at current
+// position. Letter starting with a metacharacter should be escaped with "\"
+func (p *regexParser) parseLetter() (Letter, error) {
+	escaped := p.peek() == '\\'
+	if escaped {
+		p.next()
+		if p.done() {
+			return "", p.errorf("missing escaped character")
+		}
+	}
+
+	rest := p.pattern[p.pos:]
+	var longest Letter
+	for _, l := range p.alphabet {
+		meta := strings.ContainsRune(regexMeta, []rune(string(l))[0])
+		if len(l) > len(longest) && strings.HasPrefix(rest, string(l)) &&
+			(escaped || !meta) {
+			longest = l
+		}
+	}
+	if longest == "" {
+		return "", p.errorf("letter '%c' is not in alphabet", p.peek())
+	}
+	p.pos += len(longest)
+	return longest, nil
+}
+
+func (p *regexParser) parseClass() (*regex, error) {
+	negate := false
+	if !p.done() && p.peek() == '^' {
+		p.next()
+		negate = true
+	}
+
+	selected := make(map[Letter]bool)
+	for !p.done() && p.peek() != ']' {
+		from, err := p.parseLetter()
+		if err != nil {
+			return nil, err
+		}
+		if p.done() || p.peek() != '-' {
+			selected[from] = true
+			continue
+		}
+		p.next()
+		to, err := p.parseLetter()
+		if err != nil {
+			return nil, err
+		}
+		if utf8.RuneCountInString(string(from)) != 1 ||
+			utf8.RuneCountInString(string(to)) != 1 || from > to {
+			return nil, p.errorf("invalid range '%v-%v'", from, to)
+		}
+		for _, l := range p.alphabet {
+			if l >= from && l <= to && utf8.RuneCountInString(string(l)) == 1 {
+				selected[l] = true
+			}
+		}
+	}
+	if p.done() {
+		return nil, p.errorf("missing ']'")
+	}
+	p.next()
+
+	var subs []*regex
+	for _, l := range p.alphabet {
+		if selected[l] != negate {
+			subs = append(subs, newLetterRegex(l))
+		}
+	}
+	return newUnionRegex(subs...), nil
+}
+
+// thompson builds NFA fragment for regex between states from and to using
+// Thompson's construction
+func (r *regex) thompson(m *NFA, from, to State, newState func() State) {
+	switch r.kind {
+	case regexEmpty:
+		m.SetState(from)
+		m.SetState(to)
+	case regexEpsilon:
+		m.SetEpsilonTransition(from, to) // nolint: errcheck,gas
+	case regexLetter:
+		m.SetTransition(from, r.l, to) // nolint: errcheck,gas
+	case regexUnion:
+		for _, sub := range r.subs {
+			subFrom, subTo := newState(), newState()
+			m.SetEpsilonTransition(from, subFrom) // nolint: errcheck,gas
+			sub.thompson(m, subFrom, subTo, newState)
+			m.SetEpsilonTransition(subTo, to) // nolint: errcheck,gas
+		}
+	case regexConcat:
+		current := from
+		for idx, sub := range r.subs {
+			next := to
+			if idx < len(r.subs)-1 {
+				next = newState()
+			}
+			sub.thompson(m, current, next, newState)
+			current = next
+		}
+	case regexStar:
+		subFrom, subTo := newState(), newState()
+		m.SetEpsilonTransition(from, subFrom) // nolint: errcheck,gas
+		m.SetEpsilonTransition(from, to)      // nolint: errcheck,gas
+		r.subs[0].thompson(m, subFrom, subTo, newState)
+		m.SetEpsilonTransition(subTo, subFrom) // nolint: errcheck,gas
+		m.SetEpsilonTransition(subTo, to)      // nolint: errcheck,gas
+	}
+}
+
+// CompileRegex converts regular expression over given alphabet into an
+// equivalent DFA, see regexParser for supported syntax
+func CompileRegex(pattern string, alphabet []Letter) (*DFA, error) {
+	if len(alphabet) == 0 {
+		return nil, fmt.Errorf("regex: alphabet should not be empty")
+	}
+	r, err := parseRegex(pattern, alphabet)
+	if err != nil {
+		return nil, err
+	}
+
+	m := NewNFA()
+	for _, l := range alphabet {
+		m.SetLetter(l)
+	}
+	var count int
+	newState := func() State {
+		s := State("r" + strconv.Itoa(count))
+		count++
+		return s
+	}
+	start, final := newState(), newState()
+	r.thompson(m, start, final, newState)
+	m.SetStartState(start)
+	m.SetFinalStates(final)
+
+	return m.ToDFA()
+}
+
+// ErrRegexTooLong is returned by ToRegex when the expression would have more
+// letters than allowed
+var ErrRegexTooLong = errors.New("regex: expression is too long")
+
+// ToRegex converts DFA into an equivalent regular expression using state
+// elimination. Automaton is minimized first and states that can not reach
+// final state are dropped, so the resulting expression is reasonably short.
+// Expressions may grow exponentially with the number of states, so
+// conversion stops with ErrRegexTooLong once an intermediate expression has
+// more than maxLength letters, 0 means no limit. Conversion also stops when
+// ctx is done
+// nolint: gocyclo
+func (m *DFA) ToRegex(ctx context.Context, maxLength int) (string, error) {
+	c := m.Copy()
+	err := c.Determinize()
+	if err != nil {
+		return "", err
+	}
+	err = c.Minimize(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	live := c.coReachable()
+	if !live[c.q0] {
+		return newUnionRegex().String(), nil
+	}
+
+	// generalized automaton with regex on edges, start and final are new
+	// states which are never eliminated
+	start, final := c.newStateLocked(), State("")
+	edges := make(map[doubleState]*regex)
+	addEdge := func(from, to State, r *regex) {
+		key := doubleState{a: from, b: to}
+		if old, ok := edges[key]; ok {
+			r = newUnionRegex(old, r)
+		}
+		edges[key] = r
+	}
+	addEdge(start, c.q0, newConcatRegex())
+	for de, to := range c.d {
+		if live[de.s] && live[*to] {
+			addEdge(de.s, *to, newLetterRegex(de.l))
+		}
+	}
+	for s := range c.f {
+		addEdge(s, final, newConcatRegex())
+	}
+
+	rank := c.bfsOrder()
+	remaining := make(map[State]bool, len(live))
+	for s := range live {
+		remaining[s] = true
+	}
+	for len(remaining) > 0 {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		k := nextToEliminate(remaining, rank, edges)
+		delete(remaining, k)
+
+		loop := newConcatRegex()
+		if r, ok := edges[doubleState{a: k, b: k}]; ok {
+			loop = newStarRegex(r)
+		}
+		var in, out []doubleState
+		for key := range edges {
+			if key.a == k && key.b != k {
+				out = append(out, key)
+			}
+			if key.b == k && key.a != k {
+				in = append(in, key)
+			}
+		}
+		for _, i := range in {
+			for _, o := range out {
+				addEdge(i.a, o.b, newConcatRegex(edges[i], loop, edges[o]))
+				r := edges[doubleState{a: i.a, b: o.b}]
+				if maxLength > 0 && r.size > maxLength {
+					return "", ErrRegexTooLong
+				}
+			}
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+		}
+		for key := range edges {
+			if key.a == k || key.b == k {
+				delete(edges, key)
+			}
+		}
+	}
+
+	if r, ok := edges[doubleState{a: start, b: final}]; ok {
+		return r.String(), nil
+	}
+	return newUnionRegex().String(), nil
+}
+
+// nextToEliminate picks state with the fewest paths going through it, ties
+// are broken by the order in which states are reached from the start state,
+// so that result does not depend on state names or map ordering
+func nextToEliminate(
+	remaining map[State]bool,
+	rank map[State]int,
+	edges map[doubleState]*regex,
+) State {
+	in := make(map[State]int)
+	out := make(map[State]int)
+	for key := range edges {
+		if key.a != key.b {
+			out[key.a]++
+			in[key.b]++
+		}
+	}
+
+	var best State
+	bestCost := -1
+	for s := range remaining {
+		cost := in[s] * out[s]
+		if bestCost == -1 || cost < bestCost ||
+			(cost == bestCost && rank[s] < rank[best]) {
+			best, bestCost = s, cost
+		}
+	}
+	return best
+}
@@ -0,0 +1,218 @@
+package dfa
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// randomDFA creates DFA with n states named by numbers, some transitions
+// are left missing
+func randomDFA(r *rand.Rand, n int, alphabet []Letter) *DFA {
+	m := New()
+	for _, l := range alphabet {
+		m.SetLetter(l)
+	}
+	var finals []State
+	for s := 0; s < n; s++ {
+		from := State(fmt.Sprint(s))
+		m.SetState(from)
+		for _, l := range alphabet {
+			if r.Intn(6) > 0 {
+				m.SetTransition(from, l, State(fmt.Sprint(r.Intn(n))))
+			}
+		}
+		if r.Intn(3) == 0 {
+			finals = append(finals, from)
+		}
+	}
+	m.SetStartState("0")
+	m.SetFinalStates(finals...)
+	return m
+}
+
+func TestCompileRegex(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		alphabet []Letter
+		accepted [][]Letter
+		rejected [][]Letter
+	}{
+		{
+			pattern:  "a(a|b)*",
+			alphabet: []Letter{"a", "b"},
+			accepted: [][]Letter{{"a"}, {"a", "b"}, {"a", "b", "a"}},
+			rejected: [][]Letter{{}, {"b"}, {"b", "a"}},
+		},
+		{
+			pattern:  "a+b?",
+			alphabet: []Letter{"a", "b"},
+			accepted: [][]Letter{{"a"}, {"a", "a", "b"}},
+			rejected: [][]Letter{{}, {"b"}, {"a", "b", "b"}},
+		},
+		{
+			pattern:  "[^b].[a-c]",
+			alphabet: []Letter{"a", "b", "c"},
+			accepted: [][]Letter{{"a", "b", "c"}, {"c", "c", "a"}},
+			rejected: [][]Letter{{"b", "a", "a"}, {"a", "a"}},
+		},
+		{
+			pattern:  "()|[]",
+			alphabet: []Letter{"a"},
+			accepted: [][]Letter{{}},
+			rejected: [][]Letter{{"a"}},
+		},
+		{
+			// letters that look like operators are escaped
+			pattern:  `\(\**\)`,
+			alphabet: []Letter{"(", ")", "*"},
+			accepted: [][]Letter{{"(", ")"}, {"(", "*", "*", ")"}},
+			rejected: [][]Letter{{"(", "*"}, {"*"}},
+		},
+		{
+			// the longest letter is read first
+			pattern:  "aab*",
+			alphabet: []Letter{"a", "ab"},
+			accepted: [][]Letter{{"a"}, {"a", "ab", "ab"}},
+			rejected: [][]Letter{{"a", "a"}, {"a", "a", "b"}},
+		},
+		{
+			pattern:  "(ab)*c",
+			alphabet: []Letter{"a", "ab", "c"},
+			accepted: [][]Letter{{"c"}, {"ab", "ab", "c"}},
+			rejected: [][]Letter{{"a", "c"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			m, err := CompileRegex(tt.pattern, tt.alphabet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, w := range tt.accepted {
+				if !accepts(m, w...) {
+					t.Errorf("%v is rejected", w)
+				}
+			}
+			for _, w := range tt.rejected {
+				if accepts(m, w...) {
+					t.Errorf("%v is accepted", w)
+				}
+			}
+		})
+	}
+}
+
+func TestCompileRegexErrors(t *testing.T) {
+	tests := []struct {
+		pattern string
+		err     string
+	}{
+		{"a|c", "letter 'c' is not in alphabet at position 2"},
+		{"(ab", "missing ')' at position 3"},
+		{"ab)", "unexpected ')' at position 2"},
+		{"*a", "nothing to repeat before '*' at position 0"},
+		{"a|+", "nothing to repeat before '+' at position 2"},
+		{"[ab", "missing ']' at position 3"},
+		{"[b-a]", "invalid range 'b-a' at position 4"},
+		{"a]", "unexpected ']' at position 1"},
+		{`a\`, "missing escaped character at position 2"},
+		{`\)`, "letter ')' is not in alphabet at position 1"},
+	}
+	alphabet := []Letter{"a", "b", "("}
+	for _, tt := range tests {
+		_, err := CompileRegex(tt.pattern, alphabet)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: got error %v, want %s", tt.pattern, err, tt.err)
+		}
+	}
+}
+
+// regexRoundTrip converts random automata to regex and back
+func regexRoundTrip(t *testing.T, alphabet []Letter) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		m := randomDFA(r, 1+r.Intn(5), alphabet)
+		pattern, err := m.ToRegex(context.Background(), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		back, err := CompileRegex(pattern, alphabet)
+		if err != nil {
+			t.Fatalf("%q: %v\n%s", pattern, err, m.GraphViz())
+		}
+		if eq, err := Compare(context.Background(), m, back); err != nil || !eq {
+			t.Fatalf("%q changed language\n%s", pattern, m.GraphViz())
+		}
+	}
+}
+
+func TestToRegexRoundTrip(t *testing.T) {
+	regexRoundTrip(t, []Letter{"a", "b"})
+}
+
+func TestToRegexOperatorLetters(t *testing.T) {
+	regexRoundTrip(t, []Letter{"(", "*", "|", `\`, "a"})
+}
+
+func TestToRegex(t *testing.T) {
+	tests := []struct {
+		pattern string
+		regex   string
+	}{
+		{"[]", "[]"},
+		{"()", "()"},
+		{"a", "a"},
+		{"a*", "a*"},
+		{"aa*", "a+"},
+		{"(a|b)*", "(a|b)*"},
+		{"a|()", "a?"},
+	}
+	for _, tt := range tests {
+		m, err := CompileRegex(tt.pattern, []Letter{"a", "b"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		regex, err := m.ToRegex(context.Background(), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if regex != tt.regex {
+			t.Errorf("%q converted to %q, want %q", tt.pattern, regex, tt.regex)
+		}
+	}
+}
+
+func TestToRegexMultiCharacterLetters(t *testing.T) {
+	regexRoundTrip(t, []Letter{"x", "yz", "y", "(y"})
+}
+
+func TestToRegexMaxLength(t *testing.T) {
+	// words over {a, b} with a as the third letter from the end need long
+	// expression after state elimination
+	m, err := CompileRegex("(a|b)*a(a|b)(a|b)", []Letter{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	regex, err := m.ToRegex(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.ToRegex(context.Background(), 20); err != ErrRegexTooLong {
+		t.Errorf("got error %v, want ErrRegexTooLong for %q", err, regex)
+	}
+	if _, err := m.ToRegex(context.Background(), len(regex)); err != nil {
+		t.Errorf("got error %v for limit of %d letters", err, len(regex))
+	}
+}
+
+func TestToRegexCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	m := randomDFA(rand.New(rand.NewSource(1)), 40, []Letter{"a", "b"})
+	if _, err := m.ToRegex(ctx, 0); err == nil {
+		t.Error("conversion did not stop after cancellation")
+	}
+}
@@ -0,0 +1,52 @@
+package dfa
+
+import "fmt"
+
+// Trace is a run of DFA on a word
+type Trace struct {
+	// States visited while reading the word, the first one is the start
+	// state. Letters[i] leads from States[i] to States[i+1]
+	States  []State
+	Letters Word
+	// Halted is true if some letter has no transition from the state reached
+	// before it, then the run stops there and the word is rejected
+	Halted   bool
+	Accepted bool
+}
+
+// Last returns state in which the run ended
+func (t Trace) Last() State {
+	return t.States[len(t.States)-1]
+}
+
+// Simulate runs DFA on the word and returns every visited state. Error is
+// returned if DFA is not valid or if the word has a letter that is not in
+// the alphabet, then trace holds the run up to that letter. Simulate does
+// not change the DFA, so it can be called concurrently
+func (m *DFA) Simulate(word Word) (Trace, error) {
+	var trace Trace
+	if valid, err := m.Valid(); !valid {
+		return trace, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	trace.States = []State{m.q0}
+	s := m.q0
+	for _, l := range word {
+		if !m.e[l] {
+			return trace, fmt.Errorf("letter '%v' is not in alphabet", l)
+		}
+		next := m.d[domainElement{l: l, s: s}]
+		if next == nil {
+			trace.Halted = true
+			return trace, nil
+		}
+		s = *next
+		trace.States = append(trace.States, s)
+		trace.Letters = append(trace.Letters, l)
+	}
+	trace.Accepted = m.f[s]
+	return trace, nil
+}
@@ -0,0 +1,144 @@
+package dfa
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// oddAs accepts words with odd number of a's, b has no transition
+func oddAs() *DFA {
+	m := New()
+	m.SetTransition("p", "a", "q")
+	m.SetTransition("q", "a", "p")
+	m.SetLetter("b")
+	m.SetStartState("p")
+	m.SetFinalStates("q")
+	return m
+}
+
+func TestSimulate(t *testing.T) {
+	tests := []struct {
+		word     Word
+		states   []State
+		halted   bool
+		accepted bool
+		err      bool
+	}{
+		{word: Word{}, states: []State{"p"}},
+		{word: Word{"a"}, states: []State{"p", "q"}, accepted: true},
+		{word: Word{"a", "a"}, states: []State{"p", "q", "p"}},
+		{word: Word{"a", "b", "a"}, states: []State{"p", "q"}, halted: true},
+		{word: Word{"a", "c"}, states: []State{"p", "q"}, err: true},
+	}
+	m := oddAs()
+	for _, tt := range tests {
+		trace, err := m.Simulate(tt.word)
+		if (err != nil) != tt.err {
+			t.Errorf("'%s': got error %v", tt.word, err)
+		}
+		if !reflect.DeepEqual(trace.States, tt.states) ||
+			trace.Halted != tt.halted || trace.Accepted != tt.accepted {
+			t.Errorf("'%s': got trace %+v", tt.word, trace)
+		}
+	}
+}
+
+func TestRunStreaming(t *testing.T) {
+	m := oddAs()
+	logged := make(chan State, 10)
+	m.SetTransitionLogger(func(s State) { logged <- s })
+
+	type result struct {
+		state    State
+		accepted bool
+		err      error
+	}
+	inputs := make(chan Letter)
+	done := make(chan result, 1)
+	go func() {
+		s, accepted, err := m.Run(inputs)
+		done <- result{s, accepted, err}
+	}()
+
+	// states are logged before the end of input
+	if s := <-logged; s != "p" {
+		t.Fatalf("logged %s, want start state p", s)
+	}
+	inputs <- "a"
+	select {
+	case s := <-logged:
+		if s != "q" {
+			t.Fatalf("logged %s, want q", s)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("letter was not read before the end of input")
+	}
+	inputs <- "c"
+	select {
+	case r := <-done:
+		if r.err == nil {
+			t.Error("letter not in alphabet is accepted")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("letter not in alphabet did not stop the run")
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		inputs   []Letter
+		state    State
+		accepted bool
+	}{
+		{[]Letter{EOF}, "p", false},
+		{[]Letter{"a", EOF}, "q", true},
+		{[]Letter{"a", "a"}, "p", false},
+		// run halts at b, the rest is read and the word is rejected
+		{[]Letter{"a", "b", "a", EOF}, "q", false},
+	}
+	m := oddAs()
+	for _, tt := range tests {
+		inputs := make(chan Letter, len(tt.inputs))
+		for _, l := range tt.inputs {
+			inputs <- l
+		}
+		close(inputs)
+		s, accepted, err := m.Run(inputs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s != tt.state || accepted != tt.accepted {
+			t.Errorf(
+				"%v: got %s, %v, want %s, %v",
+				tt.inputs, s, accepted, tt.state, tt.accepted,
+			)
+		}
+	}
+}
+
+func TestRunAfterStop(t *testing.T) {
+	m := oddAs()
+	// select between closed stop and waiting input is random, so try often
+	for i := 0; i < 100; i++ {
+		m.Stop()
+		inputs := make(chan Letter, 2)
+		inputs <- "a"
+		inputs <- EOF
+		s, accepted, err := m.Run(inputs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s != "p" || accepted {
+			t.Fatalf("run after stop read input and ended in %s", s)
+		}
+	}
+
+	// the next run is not stopped
+	inputs := make(chan Letter, 2)
+	inputs <- "a"
+	inputs <- EOF
+	if _, accepted, _ := m.Run(inputs); !accepted {
+		t.Error("run after stopped run did not read input")
+	}
+}
@@ -0,0 +1,361 @@
+package dfa
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SyntaxError describes problem found at some position of a text, lines
+// and columns are counted from 1
+type SyntaxError struct {
+	Line, Column int
+	Message      string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// markers of start and final states in transition tables
+const (
+	tableStart     = "→"
+	tableFinal     = "*"
+	tableMissing   = "-"
+	tableCorner    = "δ"
+	tableSeparator = " | "
+)
+
+// tableCell is a cell of transition table, literal tells which runes were
+// escaped with backslash and so have no special meaning
+type tableCell struct {
+	text    []rune
+	literal []bool
+	line    int
+	col     int
+}
+
+func (c tableCell) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{
+		Line: c.line, Column: c.col, Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// is checks if cell consists of given special text
+func (c tableCell) is(s string) bool {
+	for _, l := range c.literal {
+		if l {
+			return false
+		}
+	}
+	return string(c.text) == s
+}
+
+// splitTableRow splits line into cells separated by '|', backslash escapes
+// the next character. Pipes at both ends of the line are dropped, as in
+// Markdown tables
+func splitTableRow(line string, lineNo int) []tableCell {
+	var cells []tableCell
+	cell := tableCell{line: lineNo, col: 1}
+	// spaces is number of trailing spaces in the current cell
+	var spaces int
+	finish := func() {
+		cell.text = cell.text[:len(cell.text)-spaces]
+		cell.literal = cell.literal[:len(cell.literal)-spaces]
+		cells = append(cells, cell)
+	}
+
+	runes := []rune(line)
+	leading, trailing := false, false
+	for i := 0; i < len(runes); i++ {
+		r, col, literal := runes[i], i+1, false
+		if r == '\\' && i+1 < len(runes) {
+			i++
+			r, literal = runes[i], true
+		}
+		switch {
+		case r == '|' && !literal:
+			if len(cells) == 0 && len(cell.text) == 0 {
+				leading = true
+			}
+			finish()
+			cell = tableCell{line: lineNo, col: col + 1}
+			spaces, trailing = 0, true
+		case unicode.IsSpace(r) && !literal:
+			if len(cell.text) > 0 {
+				cell.text = append(cell.text, r)
+				cell.literal = append(cell.literal, false)
+				spaces++
+			}
+		default:
+			if len(cell.text) == 0 {
+				cell.col = col
+			}
+			cell.text = append(cell.text, r)
+			cell.literal = append(cell.literal, literal)
+			spaces, trailing = 0, false
+		}
+	}
+	finish()
+
+	if leading && trailing && len(cells) >= 3 {
+		cells = cells[1 : len(cells)-1]
+	}
+	return cells
+}
+
+// isTableRule checks if line only separates parts of the table, such as
+// "---+---" or "|---|---|"
+func isTableRule(line string) bool {
+	return strings.ContainsAny(line, "-=") &&
+		strings.Trim(line, "-=+|: \t") == ""
+}
+
+// stateCell reads markers of start and final states in front of the name of
+// the state
+func stateCell(c tableCell) (name State, start, final bool, err error) {
+	i := 0
+	for ; i < len(c.text) && !c.literal[i]; i++ {
+		switch r := c.text[i]; {
+		case r == '→' || r == '>':
+			start = true
+		case r == '-' && i+1 < len(c.text) && c.text[i+1] == '>' &&
+			!c.literal[i+1]:
+			start = true
+			i++
+		case r == '*':
+			final = true
+		case unicode.IsSpace(r):
+		default:
+			return State(c.text[i:]), start, final, nil
+		}
+	}
+	if i == len(c.text) {
+		return "", start, final, c.errorf("state name should not be empty")
+	}
+	return State(c.text[i:]), start, final, nil
+}
+
+// ParseTable reads DFA from transition table. The first row lists letters,
+// optionally after a corner cell, and every other row starts with a state
+// followed by states reached with each letter. Columns are separated by '|',
+// the start state is marked with '→', '->' or '>' and final states with '*'
+// in front of the name. Missing transitions are written as '-' or '∅' or
+// left empty. Lines of only '-', '=', '+', '|' and ':' separate parts of
+// the table and are skipped, as are empty lines and lines starting with
+// '#'. Backslash escapes the next character, so that it has no special
+// meaning. Errors describe line and column of the problem
+// nolint: gocyclo
+func ParseTable(text string) (*DFA, error) {
+	var header []tableCell
+	var rows [][]tableCell
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") ||
+			isTableRule(trimmed) {
+			continue
+		}
+		cells := splitTableRow(line, i+1)
+		if header == nil {
+			header = cells
+			continue
+		}
+		rows = append(rows, cells)
+	}
+	if header == nil {
+		return nil, &SyntaxError{
+			Line: 1, Column: 1, Message: "table should not be empty",
+		}
+	}
+	if len(rows) == 0 {
+		return nil, header[0].errorf("table should have at least one state")
+	}
+
+	// header may leave out the corner cell above states
+	width := len(rows[0])
+	letters := header
+	switch len(header) {
+	case width:
+		letters = header[1:]
+	case width - 1:
+	default:
+		return nil, rows[0][0].errorf(
+			"row has %d columns, but header has %d",
+			width, len(header),
+		)
+	}
+
+	m := New()
+	alphabet := make([]Letter, 0, len(letters))
+	for _, c := range letters {
+		l := Letter(c.text)
+		switch {
+		case l == "":
+			return nil, c.errorf("letter should not be empty")
+		case m.HasLetter(l):
+			return nil, c.errorf("letter '%s' is repeated", l)
+		}
+		m.SetLetter(l)
+		alphabet = append(alphabet, l)
+	}
+	if err := ValidateAlphabet(alphabet); err != nil {
+		cell := letters[0]
+		if e, ok := err.(*AlphabetError); ok {
+			for i, l := range alphabet {
+				if l == e.Letter {
+					cell = letters[i]
+					break
+				}
+			}
+		}
+		return nil, cell.errorf("%s", err.Error())
+	}
+
+	var finals []State
+	var start State
+	for _, row := range rows {
+		if len(row) != width {
+			return nil, row[0].errorf(
+				"row has %d columns, but previous rows have %d",
+				len(row), width,
+			)
+		}
+		name, isStart, isFinal, err := stateCell(row[0])
+		if err != nil {
+			return nil, err
+		}
+		if m.HasState(name) {
+			return nil, row[0].errorf("state '%s' is repeated", name)
+		}
+		if isStart {
+			if start != "" {
+				return nil, row[0].errorf(
+					"states '%s' and '%s' are both marked as start",
+					start, name,
+				)
+			}
+			start = name
+		}
+		if isFinal {
+			finals = append(finals, name)
+		}
+		m.SetState(name)
+	}
+	if start == "" {
+		return nil, rows[0][0].errorf(
+			"start state is not marked, mark it with '%s', '->' or '>'",
+			tableStart,
+		)
+	}
+	m.SetStartState(start)
+	m.SetFinalStates(finals...)
+
+	for _, row := range rows {
+		// state cells were read above without errors
+		from, _, _, _ := stateCell(row[0])
+		for a, c := range row[1:] {
+			if len(c.text) == 0 || c.is(tableMissing) || c.is("∅") {
+				continue
+			}
+			to := State(c.text)
+			if !m.HasState(to) {
+				return nil, c.errorf("state '%s' has no row", to)
+			}
+			m.SetTransition(from, alphabet[a], to) // nolint: errcheck
+		}
+	}
+	return m, nil
+}
+
+// escapeTableCell escapes text, so that it is read back unchanged by
+// ParseTable
+func escapeTableCell(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, "|", `\|`, -1)
+	r, _ := utf8.DecodeRuneInString(s)
+	if strings.ContainsRune("→>*-∅#", r) {
+		s = `\` + s
+	}
+	return s
+}
+
+// Table returns transition table of DFA that can be read by ParseTable.
+// States are listed in the order of Compile and letters in sorted order,
+// columns are aligned
+func (m *DFA) Table() string {
+	c := m.Compile()
+	n, k := len(c.states), len(c.letters)
+
+	markers := make([]string, n)
+	markerWidth := 0
+	for s := range markers {
+		if s == c.start {
+			markers[s] += tableStart
+		}
+		if c.final[s] {
+			markers[s] += tableFinal
+		}
+		if w := utf8.RuneCountInString(markers[s]); w > markerWidth {
+			markerWidth = w
+		}
+	}
+
+	table := make([][]string, 0, n+1)
+	header := []string{tableCorner}
+	for _, l := range c.letters {
+		header = append(header, escapeTableCell(string(l)))
+	}
+	table = append(table, header)
+	for s, name := range c.states {
+		row := []string{escapeTableCell(string(name))}
+		if markerWidth > 0 {
+			row[0] = pad(markers[s], markerWidth) + " " + row[0]
+		}
+		for a := 0; a < k; a++ {
+			to := c.Next(s, a)
+			if to < 0 {
+				row = append(row, tableMissing)
+				continue
+			}
+			row = append(row, escapeTableCell(string(c.states[to])))
+		}
+		table = append(table, row)
+	}
+
+	widths := make([]int, k+1)
+	for _, row := range table {
+		for i, cell := range row {
+			if w := utf8.RuneCountInString(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	var b strings.Builder
+	for idx, row := range table {
+		for i := range row {
+			row[i] = pad(row[i], widths[i])
+		}
+		b.WriteString(strings.TrimRight(strings.Join(row, tableSeparator), " "))
+		b.WriteString("\n")
+		if idx == 0 {
+			rule := make([]string, len(widths))
+			for i, w := range widths {
+				rule[i] = strings.Repeat("-", w)
+			}
+			b.WriteString(strings.Join(rule, "-+-"))
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// pad appends spaces to s until it has width runes
+func pad(s string, width int) string {
+	if w := utf8.RuneCountInString(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
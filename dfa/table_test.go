@@ -0,0 +1,156 @@
+package dfa
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestParseTable(t *testing.T) {
+	tests := []struct {
+		name     string
+		table    string
+		accepted []Word
+		rejected []Word
+	}{
+		{
+			name: "plain",
+			table: `
+   | a  | b
+→q0 | q1 | q0
+*q1 | q1 | q0
+`,
+			accepted: []Word{{"a"}, {"b", "a"}, {"a", "b", "a"}},
+			rejected: []Word{{}, {"b"}, {"a", "b"}},
+		},
+		{
+			name: "markdown",
+			table: `
+# textbook example
+|       | 0  | 1  |
+|-------|----|----|
+| →q0   | q1 | q2 |
+|  q1   | q1 | ∅  |
+| *q2   | q0 | q2 |
+`,
+			accepted: []Word{{"1"}, {"1", "1"}, {"1", "0", "1"}},
+			rejected: []Word{{}, {"0"}, {"0", "1"}},
+		},
+		{
+			name:     "no corner cell and ascii markers",
+			table:    "a | b\n->* q0 | q0 | \n",
+			accepted: []Word{{}, {"a", "a"}},
+			rejected: []Word{{"b"}, {"a", "b"}},
+		},
+		{
+			name:     "escaped markers and pipes",
+			table:    "δ | \\| | \\*\n>\\*q | \\-> | -\n*\\-> | - | \\*q\n",
+			accepted: []Word{{"|"}, {"|", "*", "|"}},
+			rejected: []Word{{}, {"*"}, {"|", "|"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseTable(tt.table)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, w := range tt.accepted {
+				if !m.Accepts(w) {
+					t.Errorf("'%s' is rejected", w)
+				}
+			}
+			for _, w := range tt.rejected {
+				if m.Accepts(w) {
+					t.Errorf("'%s' is accepted", w)
+				}
+			}
+		})
+	}
+}
+
+func TestParseTableErrors(t *testing.T) {
+	tests := []struct {
+		table        string
+		line, column int
+		message      string
+	}{
+		{"", 1, 1, "table should not be empty"},
+		{"  | a | b", 1, 1, "table should have at least one state"},
+		{"  | a | b\n→q0 | q1", 2, 1, "row has 2 columns, but header has 3"},
+		{"  | a | b\nq0 | q0 | q0", 2, 1, "start state is not marked"},
+		{"  | a | b\n→q0 | q0 | q9", 2, 12, "state 'q9' has no row"},
+		{"  | a | a\n→q0 | q0 | q0", 1, 9, "letter 'a' is repeated"},
+		{"  |  | a\n→q0 | q0 | q0", 1, 4, "letter should not be empty"},
+		{"  | a | ab | b\n→q0 | q0 | q0 | q0", 1, 9, "alphabet is ambiguous"},
+		{"  | ab | a | b\n→q0 | q0 | q0 | q0", 1, 5, "alphabet is ambiguous"},
+		{"  | a\n→q0 | q0\n→q1 | q0", 3, 1, "both marked as start"},
+		{"  | a\n→q0 | q0\n\nq0 | q0", 4, 1, "state 'q0' is repeated"},
+		{"  | a\n→ | q0", 2, 1, "state name should not be empty"},
+		{"  | a\n→q0 | q0\nq1 | q0 | q1", 3, 1, "but previous rows have 2"},
+		{"# comment\n\n  | a\n---+---\n→q0 |   x", 5, 9, "state 'x' has no row"},
+	}
+	for _, tt := range tests {
+		_, err := ParseTable(tt.table)
+		e, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("%q: got error %v, want *SyntaxError", tt.table, err)
+			continue
+		}
+		if e.Line != tt.line || e.Column != tt.column ||
+			!strings.Contains(e.Message, tt.message) {
+			t.Errorf(
+				"%q: got %v, want line %d, column %d: %s",
+				tt.table, err, tt.line, tt.column, tt.message,
+			)
+		}
+	}
+}
+
+func TestTableRoundTrip(t *testing.T) {
+	// names that look like markers, separators or comments
+	names := []State{
+		"q0", "*x", "->y", "a|b", `back\`, "-", "∅", "#c", "→z", ">w", "δ",
+		"long name",
+	}
+	letters := []Letter{"a", "b", "|", `\`, "*", "-"}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		m := New()
+		for _, l := range letters[:1+r.Intn(len(letters))] {
+			m.SetLetter(l)
+		}
+		perm := r.Perm(len(names))[:1+r.Intn(len(names))]
+		for _, p := range perm {
+			m.SetState(names[p])
+		}
+		for _, p := range perm {
+			for _, l := range m.Alphabet() {
+				if r.Intn(4) > 0 {
+					to := names[perm[r.Intn(len(perm))]]
+					m.SetTransition(names[p], l, to) // nolint: errcheck
+				}
+			}
+		}
+		m.SetStartState(names[perm[0]])
+		if r.Intn(2) == 0 {
+			m.SetFinalStates(names[perm[r.Intn(len(perm))]])
+		}
+
+		table := m.Table()
+		back, err := ParseTable(table)
+		if err != nil {
+			t.Fatalf("%v\n%s", err, table)
+		}
+		if back.Table() != table {
+			t.Fatalf("table changed\n%s\n%s", table, back.Table())
+		}
+		if len(back.States()) != len(m.States()) {
+			t.Fatalf("states changed\n%s", table)
+		}
+		if eq, _ := Compare(context.Background(), m, back); !eq {
+			t.Fatalf("language changed\n%s", table)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package dfa
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Word is a sequence of letters. Letters may have several characters, so
+// words are kept as sequences rather than strings
+type Word []Letter
+
+// String concatenates letters of the word, which gives a unique string only
+// for alphabets accepted by ValidateAlphabet
+func (w Word) String() string {
+	var b strings.Builder
+	for _, l := range w {
+		b.WriteString(string(l))
+	}
+	return b.String()
+}
+
+// Strings returns letters of the word as strings
+func (w Word) Strings() []string {
+	result := make([]string, 0, len(w))
+	for _, l := range w {
+		result = append(result, string(l))
+	}
+	return result
+}
+
+// AlphabetError describes why letters can not be used as alphabet, Letter is
+// the letter that causes the problem
+type AlphabetError struct {
+	Letter  Letter
+	Message string
+}
+
+func (e *AlphabetError) Error() string {
+	return e.Message
+}
+
+func alphabetErrorf(l Letter, format string, args ...interface{}) error {
+	return &AlphabetError{Letter: l, Message: fmt.Sprintf(format, args...)}
+}
+
+// ValidateAlphabet checks that letters can be used as alphabet: they are not
+// empty or reserved EOF, and every string made of letters can be split into
+// letters in only one way, which is checked using Sardinas-Patterson
+// algorithm. Error is *AlphabetError, for a string that can be split in two
+// ways it names the longest letter of both splits
+func ValidateAlphabet(alphabet []Letter) error {
+	letters := make([]Letter, 0, len(alphabet))
+	seen := make(map[Letter]bool, len(alphabet))
+	for _, l := range alphabet {
+		switch {
+		case l == "":
+			return alphabetErrorf(l, "letter should not be empty")
+		case l == EOF:
+			return alphabetErrorf(l, "letter '%v' is reserved", l)
+		case seen[l]:
+			return alphabetErrorf(l, "letter '%v' is repeated", l)
+		}
+		seen[l] = true
+		letters = append(letters, l)
+	}
+	sort.Slice(letters, func(i, j int) bool { return letters[i] < letters[j] })
+
+	// dangling suffix is what remains when one sequence of letters is a
+	// proper prefix of another one, longer is the sequence that is ahead
+	type dangling struct {
+		suffix        string
+		longer, other Word
+	}
+	var queue []dangling
+	visited := make(map[string]bool)
+	push := func(suffix string, longer, other Word) {
+		if !visited[suffix] {
+			visited[suffix] = true
+			queue = append(queue, dangling{
+				suffix: suffix, longer: longer, other: other,
+			})
+		}
+	}
+	for _, a := range letters {
+		for _, b := range letters {
+			if a != b && strings.HasPrefix(string(b), string(a)) {
+				push(string(b[len(a):]), Word{b}, Word{a})
+			}
+		}
+	}
+
+	for i := 0; i < len(queue); i++ {
+		d := queue[i]
+		for _, l := range letters {
+			other := make(Word, len(d.other), len(d.other)+1)
+			copy(other, d.other)
+			other = append(other, l)
+			switch {
+			case string(l) == d.suffix:
+				return alphabetErrorf(
+					longestLetter(d.longer, other),
+					"alphabet is ambiguous, '%s' can be read as %q and %q",
+					d.longer, d.longer.Strings(), other.Strings(),
+				)
+			case strings.HasPrefix(string(l), d.suffix):
+				push(string(l[len(d.suffix):]), other, d.longer)
+			case strings.HasPrefix(d.suffix, string(l)):
+				push(d.suffix[len(l):], d.longer, other)
+			}
+		}
+	}
+	return nil
+}
+
+// longestLetter returns the first of the longest letters of the words
+func longestLetter(words ...Word) Letter {
+	var longest Letter
+	for _, w := range words {
+		for _, l := range w {
+			if len(l) > len(longest) {
+				longest = l
+			}
+		}
+	}
+	return longest
+}
+
+// ParseWord splits string into letters of the alphabet. Alphabet should be
+// accepted by ValidateAlphabet, otherwise some split of the string is chosen
+func ParseWord(s string, alphabet []Letter) (Word, error) {
+	// split[i] is letter that starts a valid split of s[i:], or -1
+	split := make([]int, len(s)+1)
+	for i := range split {
+		split[i] = -1
+	}
+	for i := len(s) - 1; i >= 0; i-- {
+		for idx, l := range alphabet {
+			end := i + len(l)
+			if l == "" || !strings.HasPrefix(s[i:], string(l)) {
+				continue
+			}
+			if end == len(s) || split[end] != -1 {
+				split[i] = idx
+				break
+			}
+		}
+	}
+	if len(s) > 0 && split[0] == -1 {
+		return nil, fmt.Errorf("'%s' can not be split into letters", s)
+	}
+
+	word := Word{}
+	for i := 0; i < len(s); i += len(alphabet[split[i]]) {
+		word = append(word, alphabet[split[i]])
+	}
+	return word, nil
+}
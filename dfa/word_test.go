@@ -0,0 +1,100 @@
+package dfa
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateAlphabet(t *testing.T) {
+	tests := []struct {
+		name     string
+		alphabet []Letter
+		letter   Letter
+		message  string
+	}{
+		{"single characters", []Letter{"a", "b", "c"}, "", ""},
+		{"prefix code", []Letter{"a", "ba", "bb"}, "", ""},
+		{"prefix of another letter", []Letter{"a", "ab"}, "", ""},
+		{"not a prefix or suffix code", []Letter{"0", "01", "11"}, "", ""},
+		{"concatenation", []Letter{"a", "ab", "b"}, "ab", "'ab' can be read as"},
+		{"overlapping letters", []Letter{"aa", "aaa"}, "aaa", "alphabet is ambiguous"},
+		{"longer ambiguity", []Letter{"0", "01", "10"}, "", "'010' can be read as"},
+		{"empty", []Letter{"a", ""}, "", "should not be empty"},
+		{"end of input", []Letter{"a", EOF}, EOF, "'EOF' is reserved"},
+		{"repeated", []Letter{"a", "b", "a"}, "a", "'a' is repeated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAlphabet(tt.alphabet)
+			if tt.message == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			alphabetErr, ok := err.(*AlphabetError)
+			if !ok {
+				t.Fatalf("got error %v, want *AlphabetError", err)
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("error %q, want %q", err, tt.message)
+			}
+			if tt.letter != "" && alphabetErr.Letter != tt.letter {
+				t.Errorf("error letter '%s', want '%s'", alphabetErr.Letter, tt.letter)
+			}
+		})
+	}
+}
+
+func TestParseWord(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		alphabet []Letter
+		want     []string
+		err      string
+	}{
+		{"empty", "", []Letter{"a"}, []string{}, ""},
+		{"single characters", "abba", []Letter{"a", "b"}, []string{"a", "b", "b", "a"}, ""},
+		{"longer letter", "aab", []Letter{"a", "ab"}, []string{"a", "ab"}, ""},
+		{"shorter letter", "aba", []Letter{"ab", "a"}, []string{"ab", "a"}, ""},
+		// the first letter depends on the parity of the number of 1's
+		{"odd lookahead", "0111", []Letter{"0", "01", "11"}, []string{"01", "11"}, ""},
+		{"even lookahead", "01111", []Letter{"0", "01", "11"}, []string{"0", "11", "11"}, ""},
+		{"no split", "abb", []Letter{"a", "ab"}, nil, "'abb' can not be split"},
+		{"unknown letter", "ac", []Letter{"a", "b"}, nil, "'ac' can not be split"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			word, err := ParseWord(tt.s, tt.alphabet)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(word.Strings(), tt.want) {
+				t.Errorf("got word %q, want %q", word.Strings(), tt.want)
+			}
+			if word.String() != tt.s {
+				t.Errorf("word %q joins to '%s'", word.Strings(), word)
+			}
+		})
+	}
+}
+
+func TestParseWordAmbiguous(t *testing.T) {
+	// any split is returned for ambiguous alphabet, but it should be valid
+	alphabet := []Letter{"a", "ab", "b"}
+	word, err := ParseWord("abab", alphabet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if word.String() != "abab" {
+		t.Errorf("word %q joins to '%s'", word.Strings(), word)
+	}
+}
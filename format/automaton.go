@@ -0,0 +1,118 @@
+// Package format reads and writes automata in file formats of other tools
+package format
+
+import (
+	"dfa-grader/dfa"
+
+	"github.com/pkg/errors"
+)
+
+// transition of automaton read from a file, empty letter marks epsilon
+// transition
+type transition struct {
+	from, to dfa.State
+	letter   dfa.Letter
+}
+
+// automaton is read from a file before it is known if it is deterministic
+type automaton struct {
+	states      []dfa.State
+	letters     []dfa.Letter
+	start       dfa.State
+	finals      []dfa.State
+	transitions []transition
+}
+
+// addLetter adds letter to alphabet unless it is already there
+func (a *automaton) addLetter(l dfa.Letter) {
+	for _, known := range a.letters {
+		if known == l {
+			return
+		}
+	}
+	a.letters = append(a.letters, l)
+}
+
+// validate checks that automaton has states, start state and unambiguous
+// alphabet
+func (a *automaton) validate() error {
+	if len(a.states) == 0 {
+		return errors.New("automaton should have at least one state")
+	}
+	if a.start == "" {
+		return errors.New("automaton has no start state")
+	}
+	return dfa.ValidateAlphabet(a.letters)
+}
+
+// deterministic checks if automaton has no epsilon transitions and at most
+// one transition from every state with every letter
+func (a *automaton) deterministic() bool {
+	type key struct {
+		s dfa.State
+		l dfa.Letter
+	}
+	seen := make(map[key]dfa.State, len(a.transitions))
+	for _, t := range a.transitions {
+		if t.letter == "" {
+			return false
+		}
+		k := key{s: t.from, l: t.letter}
+		if to, ok := seen[k]; ok && to != t.to {
+			return false
+		}
+		seen[k] = t.to
+	}
+	return true
+}
+
+func (a *automaton) nfa() (*dfa.NFA, error) {
+	if err := a.validate(); err != nil {
+		return nil, err
+	}
+	m := dfa.NewNFA()
+	for _, l := range a.letters {
+		m.SetLetter(l)
+	}
+	for _, s := range a.states {
+		m.SetState(s)
+	}
+	m.SetStartState(a.start)
+	m.SetFinalStates(a.finals...)
+	for _, t := range a.transitions {
+		if t.letter == "" {
+			m.SetEpsilonTransition(t.from, t.to) // nolint: errcheck
+			continue
+		}
+		m.SetTransition(t.from, t.letter, t.to) // nolint: errcheck
+	}
+	return m, nil
+}
+
+// dfa builds DFA with the same states if automaton is deterministic,
+// otherwise automaton is converted using subset construction
+func (a *automaton) dfa() (*dfa.DFA, error) {
+	if !a.deterministic() {
+		m, err := a.nfa()
+		if err != nil {
+			return nil, err
+		}
+		return m.ToDFA()
+	}
+	if err := a.validate(); err != nil {
+		return nil, err
+	}
+	m := dfa.New()
+	for _, l := range a.letters {
+		m.SetLetter(l)
+	}
+	for _, s := range a.states {
+		m.SetState(s)
+	}
+	m.SetStartState(a.start)
+	m.SetFinalStates(a.finals...)
+	for _, t := range a.transitions {
+		m.SetTransition(t.from, t.letter, t.to) // nolint: errcheck
+	}
+	return m, nil
+}
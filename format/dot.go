@@ -0,0 +1,626 @@
+package format
+
+import (
+	"dfa-grader/dfa"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+func syntaxErrorf(
+	line, col int,
+	format string,
+	args ...interface{},
+) error {
+	return &dfa.SyntaxError{
+		Line: line, Column: col, Message: fmt.Sprintf(format, args...),
+	}
+}
+
+type dotTokenKind int
+
+const (
+	dotEOF dotTokenKind = iota
+	dotID
+	dotPunct
+)
+
+type dotToken struct {
+	kind dotTokenKind
+	text string
+	// plain IDs may be keywords, quoted and HTML strings never are
+	plain     bool
+	line, col int
+}
+
+// is checks if token is given punctuation or keyword
+func (t dotToken) is(s string) bool {
+	switch t.kind {
+	case dotPunct:
+		return t.text == s
+	case dotID:
+		return t.plain && strings.EqualFold(t.text, s)
+	}
+	return false
+}
+
+func (t dotToken) describe() string {
+	if t.kind == dotEOF {
+		return "end of file"
+	}
+	return fmt.Sprintf("'%s'", t.text)
+}
+
+// dotLexer splits DOT file into tokens
+type dotLexer struct {
+	src       string
+	pos       int
+	line, col int
+}
+
+func (l *dotLexer) peek() rune {
+	if l.pos >= len(l.src) {
+		return -1
+	}
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	return r
+}
+
+func (l *dotLexer) next() rune {
+	r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+	l.pos += size
+	if r == '\n' {
+		l.line++
+		l.col = 1
+	} else {
+		l.col++
+	}
+	return r
+}
+
+func isDOTIDRune(r rune) bool {
+	return r == '_' || r == '.' || r >= utf8.RuneSelf ||
+		unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// skip skips white space and comments, lines starting with '#' are
+// output of C preprocessor and are skipped too
+func (l *dotLexer) skip() error {
+	for l.pos < len(l.src) {
+		rest := l.src[l.pos:]
+		switch {
+		case unicode.IsSpace(l.peek()):
+			l.next()
+		case strings.HasPrefix(rest, "//"),
+			l.col == 1 && strings.HasPrefix(rest, "#"):
+			for l.pos < len(l.src) && l.peek() != '\n' {
+				l.next()
+			}
+		case strings.HasPrefix(rest, "/*"):
+			line, col := l.line, l.col
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				return syntaxErrorf(line, col, "comment is not closed")
+			}
+			for stop := l.pos + end + 4; l.pos < stop; {
+				l.next()
+			}
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// nolint: gocyclo
+func (l *dotLexer) token() (dotToken, error) {
+	if err := l.skip(); err != nil {
+		return dotToken{}, err
+	}
+	t := dotToken{line: l.line, col: l.col}
+	if l.pos >= len(l.src) {
+		return t, nil
+	}
+
+	rest := l.src[l.pos:]
+	switch r := l.peek(); {
+	case strings.HasPrefix(rest, "->"), strings.HasPrefix(rest, "--"):
+		l.next()
+		l.next()
+		t.kind, t.text = dotPunct, rest[:2]
+	case strings.ContainsRune("{}[];,=:", r):
+		l.next()
+		t.kind, t.text = dotPunct, string(r)
+	case r == '"':
+		l.next()
+		var b strings.Builder
+		for {
+			if l.pos >= len(l.src) {
+				return t, syntaxErrorf(t.line, t.col, "string is not closed")
+			}
+			c := l.next()
+			if c == '"' {
+				break
+			}
+			if c == '\\' && l.pos < len(l.src) {
+				switch l.peek() {
+				case '"', '\\':
+					c = l.next()
+				case '\n':
+					// line continuation
+					l.next()
+					continue
+				}
+			}
+			b.WriteRune(c)
+		}
+		t.kind, t.text = dotID, b.String()
+	case r == '<':
+		l.next()
+		depth := 1
+		start := l.pos
+		for depth > 0 {
+			if l.pos >= len(l.src) {
+				return t, syntaxErrorf(t.line, t.col, "HTML string is not closed")
+			}
+			switch l.next() {
+			case '<':
+				depth++
+			case '>':
+				depth--
+			}
+		}
+		t.kind, t.text = dotID, l.src[start:l.pos-1]
+	case isDOTIDRune(r) || r == '-':
+		start := l.pos
+		l.next()
+		for l.pos < len(l.src) && isDOTIDRune(l.peek()) {
+			l.next()
+		}
+		t.kind, t.text, t.plain = dotID, l.src[start:l.pos], true
+	default:
+		return t, syntaxErrorf(t.line, t.col, "unexpected character '%c'", r)
+	}
+	return t, nil
+}
+
+// dotNode is a node of DOT graph, nodes with shape point, none or
+// plaintext, invisible nodes and node with empty name only mark the start
+// state
+type dotNode struct {
+	name  string
+	attrs map[string]string
+}
+
+func (n *dotNode) marker() bool {
+	if n.name == "" {
+		return true
+	}
+	switch strings.ToLower(n.attrs["shape"]) {
+	case "point", "none", "plaintext", "plain":
+		return true
+	}
+	return strings.Contains(strings.ToLower(n.attrs["style"]), "invis")
+}
+
+func (n *dotNode) final() bool {
+	if strings.EqualFold(n.attrs["shape"], "doublecircle") {
+		return true
+	}
+	peripheries, err := strconv.Atoi(n.attrs["peripheries"])
+	return err == nil && peripheries >= 2
+}
+
+type dotEdge struct {
+	from, to  *dotNode
+	attrs     map[string]string
+	line, col int
+}
+
+// dotParser reads a directed graph, attributes given by node and edge
+// statements apply to nodes and edges created later in the same graph or
+// subgraph
+type dotParser struct {
+	tokens []dotToken
+	pos    int
+
+	nodes map[string]*dotNode
+	order []*dotNode
+	edges []dotEdge
+}
+
+func (p *dotParser) peek() dotToken {
+	return p.tokens[p.pos]
+}
+
+func (p *dotParser) next() dotToken {
+	t := p.tokens[p.pos]
+	if t.kind != dotEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *dotParser) errorf(
+	t dotToken,
+	format string,
+	args ...interface{},
+) error {
+	return syntaxErrorf(t.line, t.col, format, args...)
+}
+
+func (p *dotParser) expect(s string) error {
+	if t := p.next(); !t.is(s) {
+		return p.errorf(t, "expected '%s', got %s", s, t.describe())
+	}
+	return nil
+}
+
+func (p *dotParser) id() (dotToken, error) {
+	t := p.next()
+	if t.kind != dotID {
+		return t, p.errorf(t, "expected identifier, got %s", t.describe())
+	}
+	return t, nil
+}
+
+func (p *dotParser) graph() error {
+	if p.peek().is("strict") {
+		p.next()
+	}
+	t := p.next()
+	if t.is("graph") {
+		return p.errorf(t, "automaton should be a digraph")
+	}
+	if !t.is("digraph") {
+		return p.errorf(t, "expected 'digraph', got %s", t.describe())
+	}
+	if p.peek().kind == dotID {
+		p.next()
+	}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	err := p.statements(map[string]string{}, map[string]string{})
+	if err != nil {
+		return err
+	}
+	if err := p.expect("}"); err != nil {
+		return err
+	}
+	if t := p.next(); t.kind != dotEOF {
+		return p.errorf(t, "expected end of file, got %s", t.describe())
+	}
+	return nil
+}
+
+// statements reads statements until closing brace
+// nolint: gocyclo
+func (p *dotParser) statements(nodeAttrs, edgeAttrs map[string]string) error {
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == dotEOF || t.is("}"):
+			return nil
+		case t.is(";"):
+			p.next()
+			continue
+		case t.is("graph"):
+			p.next()
+			if _, err := p.attributes(map[string]string{}); err != nil {
+				return err
+			}
+		case t.is("node"):
+			p.next()
+			attrs, err := p.attributes(nodeAttrs)
+			if err != nil {
+				return err
+			}
+			nodeAttrs = attrs
+		case t.is("edge"):
+			p.next()
+			attrs, err := p.attributes(edgeAttrs)
+			if err != nil {
+				return err
+			}
+			edgeAttrs = attrs
+		case t.is("subgraph") || t.is("{"):
+			if err := p.subgraph(nodeAttrs, edgeAttrs); err != nil {
+				return err
+			}
+		case t.kind == dotID:
+			if err := p.nodeOrEdge(nodeAttrs, edgeAttrs); err != nil {
+				return err
+			}
+		default:
+			return p.errorf(t, "unexpected %s", t.describe())
+		}
+	}
+}
+
+// subgraph reads subgraph statement, attributes set inside of subgraph
+// apply only there
+func (p *dotParser) subgraph(nodeAttrs, edgeAttrs map[string]string) error {
+	if p.peek().is("subgraph") {
+		p.next()
+		if p.peek().kind == dotID {
+			p.next()
+		}
+	}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	if err := p.statements(nodeAttrs, edgeAttrs); err != nil {
+		return err
+	}
+	err := p.expect("}")
+	if err == nil && p.peek().is("->") {
+		return p.errorf(p.peek(), "edges from subgraphs are not supported")
+	}
+	return err
+}
+
+// nodeOrEdge reads node statement, edge statement or graph attribute
+func (p *dotParser) nodeOrEdge(nodeAttrs, edgeAttrs map[string]string) error {
+	first, err := p.nodeID()
+	if err != nil {
+		return err
+	}
+	if p.peek().is("=") {
+		p.next()
+		_, err := p.id()
+		return err
+	}
+
+	ends := []dotToken{first}
+	for {
+		t := p.peek()
+		if t.is("--") {
+			return p.errorf(t, "automaton should be a digraph")
+		}
+		if !t.is("->") {
+			break
+		}
+		p.next()
+		if p.peek().is("{") || p.peek().is("subgraph") {
+			return p.errorf(p.peek(), "edges to subgraphs are not supported")
+		}
+		end, err := p.nodeID()
+		if err != nil {
+			return err
+		}
+		ends = append(ends, end)
+	}
+
+	if len(ends) == 1 {
+		attrs, err := p.attributes(map[string]string{})
+		if err != nil {
+			return err
+		}
+		n := p.node(first.text, nodeAttrs)
+		for k, v := range attrs {
+			n.attrs[k] = v
+		}
+		return nil
+	}
+
+	attrs, err := p.attributes(edgeAttrs)
+	if err != nil {
+		return err
+	}
+	for i := 1; i < len(ends); i++ {
+		p.edges = append(p.edges, dotEdge{
+			from:  p.node(ends[i-1].text, nodeAttrs),
+			to:    p.node(ends[i].text, nodeAttrs),
+			attrs: attrs,
+			line:  ends[i-1].line,
+			col:   ends[i-1].col,
+		})
+	}
+	return nil
+}
+
+// nodeID reads node name, ports only affect drawing and are skipped
+func (p *dotParser) nodeID() (dotToken, error) {
+	t, err := p.id()
+	if err != nil {
+		return t, err
+	}
+	for i := 0; i < 2 && p.peek().is(":"); i++ {
+		p.next()
+		if _, err := p.id(); err != nil {
+			return t, err
+		}
+	}
+	return t, nil
+}
+
+// node finds node by name, new nodes get default attributes
+func (p *dotParser) node(name string, defaults map[string]string) *dotNode {
+	if n, ok := p.nodes[name]; ok {
+		return n
+	}
+	n := &dotNode{name: name, attrs: make(map[string]string, len(defaults))}
+	for k, v := range defaults {
+		n.attrs[k] = v
+	}
+	p.nodes[name] = n
+	p.order = append(p.order, n)
+	return n
+}
+
+// attributes reads optional attribute lists, returned attributes are
+// defaults overridden by the read ones
+func (p *dotParser) attributes(
+	defaults map[string]string,
+) (map[string]string, error) {
+	attrs := make(map[string]string, len(defaults))
+	for k, v := range defaults {
+		attrs[k] = v
+	}
+	for p.peek().is("[") {
+		p.next()
+		for !p.peek().is("]") {
+			key, err := p.id()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			value, err := p.id()
+			if err != nil {
+				return nil, err
+			}
+			attrs[strings.ToLower(key.text)] = value.text
+			if p.peek().is(",") || p.peek().is(";") {
+				p.next()
+			}
+		}
+		p.next()
+	}
+	return attrs, nil
+}
+
+// splitLabel splits edge label into comma separated letters, backslash
+// escapes commas and backslashes in letters
+func splitLabel(label string) []string {
+	var parts []string
+	var b strings.Builder
+	for i := 0; i < len(label); i++ {
+		switch {
+		case label[i] == '\\' && i+1 < len(label) &&
+			(label[i+1] == ',' || label[i+1] == '\\'):
+			i++
+			b.WriteByte(label[i])
+		case label[i] == ',':
+			parts = append(parts, strings.TrimSpace(b.String()))
+			b.Reset()
+		default:
+			b.WriteByte(label[i])
+		}
+	}
+	return append(parts, strings.TrimSpace(b.String()))
+}
+
+// epsilonLabels are labels of transitions that do not read a letter
+var epsilonLabels = map[string]bool{"ε": true, "λ": true, "eps": true}
+
+// automaton converts parsed graph into automaton
+func (p *dotParser) automaton() (*automaton, error) {
+	a := &automaton{}
+	for _, n := range p.order {
+		if n.marker() {
+			continue
+		}
+		a.states = append(a.states, dfa.State(n.name))
+		if n.final() {
+			a.finals = append(a.finals, dfa.State(n.name))
+		}
+	}
+
+	for _, e := range p.edges {
+		at := func(format string, args ...interface{}) error {
+			return syntaxErrorf(e.line, e.col, format, args...)
+		}
+		switch {
+		case e.to.marker():
+			return nil, at("edge leads to start marker '%s'", e.to.name)
+		case e.from.marker():
+			if a.start != "" && a.start != dfa.State(e.to.name) {
+				return nil, at(
+					"states '%s' and '%s' are both marked as start",
+					a.start, e.to.name,
+				)
+			}
+			a.start = dfa.State(e.to.name)
+			continue
+		}
+
+		label, ok := e.attrs["label"]
+		if !ok {
+			return nil, at(
+				"transition from '%s' to '%s' has no label",
+				e.from.name, e.to.name,
+			)
+		}
+		for _, part := range splitLabel(label) {
+			if part == "" {
+				return nil, at("label '%s' has empty letter", label)
+			}
+			l := dfa.Letter(part)
+			if epsilonLabels[part] {
+				l = ""
+			} else {
+				a.addLetter(l)
+			}
+			a.transitions = append(a.transitions, transition{
+				from:   dfa.State(e.from.name),
+				to:     dfa.State(e.to.name),
+				letter: l,
+			})
+		}
+	}
+
+	if a.start == "" {
+		return nil, errors.New(
+			"start state is not marked, add an edge to it from a node " +
+				"with shape=point or shape=none",
+		)
+	}
+	return a, nil
+}
+
+func readDOT(r io.Reader) (*automaton, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	lexer := &dotLexer{src: string(src), line: 1, col: 1}
+	p := &dotParser{nodes: make(map[string]*dotNode)}
+	for {
+		t, err := lexer.token()
+		if err != nil {
+			return nil, err
+		}
+		p.tokens = append(p.tokens, t)
+		if t.kind == dotEOF {
+			break
+		}
+	}
+	if err := p.graph(); err != nil {
+		return nil, err
+	}
+	return p.automaton()
+}
+
+// ReadDOT reads automaton from GraphViz DOT digraph, such as written by
+// dfa.DFA.GraphViz. Every node is a state, except nodes with shape point,
+// none or plaintext, invisible nodes and node with empty name, an edge from
+// such node marks the start state. States with shape doublecircle are
+// final. Edge labels list comma separated letters, where backslash escapes
+// commas and backslashes, letters ε, λ and eps mark epsilon transitions.
+// Deterministic automata keep their states, others are converted using
+// subset construction. Alphabet consists of letters read by transitions
+func ReadDOT(r io.Reader) (*dfa.DFA, error) {
+	a, err := readDOT(r)
+	if err != nil {
+		return nil, err
+	}
+	return a.dfa()
+}
+
+// ReadDOTNFA reads automaton from GraphViz DOT digraph as NFA, see ReadDOT
+func ReadDOTNFA(r io.Reader) (*dfa.NFA, error) {
+	a, err := readDOT(r)
+	if err != nil {
+		return nil, err
+	}
+	return a.nfa()
+}
@@ -0,0 +1,126 @@
+package format
+
+import (
+	"context"
+	"dfa-grader/dfa"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestDOTRoundTrip(t *testing.T) {
+	// letters and names that need quoting or escaping in DOT
+	letters := []dfa.Letter{"a", "b", "c,d", `x"y`, `\`}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		m := randomDFA(r, 1+r.Intn(6), letters)
+		m.SetTransition(`we"ird\name`, "a", "s0") // nolint: errcheck
+
+		diagram := m.GraphViz()
+		back, err := ReadDOT(strings.NewReader(diagram))
+		if err != nil {
+			t.Fatalf("%v\n%s", err, diagram)
+		}
+		// letters without transitions are not in the diagram
+		for _, l := range m.Alphabet() {
+			back.SetLetter(l)
+		}
+		if eq, _ := dfa.Compare(context.Background(), m, back); !eq {
+			t.Fatalf("language changed\n%s", diagram)
+		}
+		if back.GraphViz() != diagram {
+			t.Fatalf("diagram changed\n%s\n%s", diagram, back.GraphViz())
+		}
+	}
+}
+
+func TestReadDOT(t *testing.T) {
+	src := `
+# preprocessor line
+strict digraph "my fa" {
+	rankdir = LR; /* comment
+	spanning lines */
+	node [shape = doublecircle]; q2 "q3";
+	node [shape = circle]
+	init [shape=point]
+	init -> q0
+	q0 -> q1 [label="a, b"] // comment
+	q1 -> q2 -> q3 [label=<a>]
+	q3 -> q0 [label="ε"]
+	subgraph cluster_x { node [shape=doublecircle]; q4 }
+	q4:n -> q0:s [label = b]
+	q5
+}`
+	tests := []struct {
+		word     dfa.Word
+		accepted bool
+	}{
+		{dfa.Word{}, false},
+		{dfa.Word{"a"}, false},
+		{dfa.Word{"b", "a"}, true},
+		{dfa.Word{"a", "a", "a"}, true},
+		{dfa.Word{"a", "a", "a", "b", "a"}, true},
+		{dfa.Word{"a", "b"}, false},
+	}
+
+	m, err := ReadDOT(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		if m.Accepts(tt.word) != tt.accepted {
+			t.Errorf("'%s' accepted is %v", tt.word, !tt.accepted)
+		}
+	}
+	n, err := ReadDOTNFA(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !n.HasState("q4") || !n.HasState("q5") || n.HasState("init") {
+		t.Error("wrong states of NFA")
+	}
+}
+
+func TestReadDOTErrors(t *testing.T) {
+	tests := []struct {
+		src          string
+		line, column int
+		message      string
+	}{
+		{"graph { a -- b }", 1, 1, "automaton should be a digraph"},
+		{"digraph { a -> b }", 1, 11, "has no label"},
+		{
+			"digraph {\n  s [shape=point]\n  s -> a\n  a -> b [label=\"a,,b\"]\n}",
+			4, 3, "label 'a,,b' has empty letter",
+		},
+		{
+			"digraph {\n  s [shape=point]; s -> a\n  a -> { b }\n}",
+			3, 8, "edges to subgraphs are not supported",
+		},
+		{"digraph {\n  a -> b [label=\"a\"\n}", 3, 1, "expected identifier"},
+		{"digraph {\n  a -> b [label=\"a]\n}", 2, 17, "string is not closed"},
+		{"digraph {\n  a -> b [label=<a]\n}", 2, 17, "HTML string is not closed"},
+		{"digraph { s [shape=point]; s -> a } x", 1, 37, "expected end of file"},
+		{
+			"digraph { s [shape=point]; s -> a; t [shape=point]; t -> b; }",
+			1, 53, "both marked as start",
+		},
+		{"digraph {\n  /* a ", 2, 3, "comment is not closed"},
+		{"digraph {\n  a ! b\n}", 2, 5, "unexpected character '!'"},
+	}
+	for _, tt := range tests {
+		_, err := ReadDOT(strings.NewReader(tt.src))
+		e, ok := err.(*dfa.SyntaxError)
+		if !ok {
+			t.Errorf("%q: got error %v, want *dfa.SyntaxError", tt.src, err)
+			continue
+		}
+		if e.Line != tt.line || e.Column != tt.column ||
+			!strings.Contains(e.Message, tt.message) {
+			t.Errorf(
+				"%q: got %v, want line %d, column %d: %s",
+				tt.src, err, tt.line, tt.column, tt.message,
+			)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package format
+
+import (
+	"dfa-grader/dfa"
+	"encoding/xml"
+	"io"
+	"math"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	jflapTypeFA = "fa"
+	jflapHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>` +
+		"<!--Created with dfa-grader.-->\n"
+)
+
+// jflapStructure is the root element of JFLAP file. JFLAP 7 keeps states and
+// transitions in automaton element, older versions keep them in the root
+type jflapStructure struct {
+	XMLName   xml.Name        `xml:"structure"`
+	Type      string          `xml:"type"`
+	Automaton *jflapAutomaton `xml:"automaton"`
+
+	States      []jflapState      `xml:"state"`
+	Transitions []jflapTransition `xml:"transition"`
+}
+
+type jflapAutomaton struct {
+	States      []jflapState      `xml:"state"`
+	Transitions []jflapTransition `xml:"transition"`
+}
+
+type jflapState struct {
+	ID      string    `xml:"id,attr"`
+	Name    string    `xml:"name,attr"`
+	X       float64   `xml:"x"`
+	Y       float64   `xml:"y"`
+	Initial *struct{} `xml:"initial"`
+	Final   *struct{} `xml:"final"`
+}
+
+// jflapTransition reads letter, empty read is a lambda transition
+type jflapTransition struct {
+	From string `xml:"from"`
+	To   string `xml:"to"`
+	Read string `xml:"read"`
+}
+
+// ReadJFLAP reads finite automaton from JFLAP file. Deterministic automata
+// keep their states, others are converted using subset construction.
+// JFLAP files have no alphabet, so it consists of characters read by
+// transitions. As in JFLAP, transition that reads several characters reads
+// them one by one, passing through new states named after the state and
+// characters read so far, e.g. "q0.ab"
+func ReadJFLAP(r io.Reader) (*dfa.DFA, error) {
+	a, err := readJFLAP(r)
+	if err != nil {
+		return nil, err
+	}
+	return a.dfa()
+}
+
+// ReadJFLAPNFA reads finite automaton from JFLAP file as NFA, see ReadJFLAP
+func ReadJFLAPNFA(r io.Reader) (*dfa.NFA, error) {
+	a, err := readJFLAP(r)
+	if err != nil {
+		return nil, err
+	}
+	return a.nfa()
+}
+
+// nolint: gocyclo
+func readJFLAP(r io.Reader) (*automaton, error) {
+	var file jflapStructure
+	if err := xml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, errors.Wrap(err, "could not parse JFLAP file")
+	}
+	if file.Type != jflapTypeFA {
+		return nil, errors.Errorf(
+			"JFLAP automaton type should be '%s', got '%s'",
+			jflapTypeFA, file.Type,
+		)
+	}
+	states, transitions := file.States, file.Transitions
+	if file.Automaton != nil {
+		states = append(states, file.Automaton.States...)
+		transitions = append(transitions, file.Automaton.Transitions...)
+	}
+
+	a := &automaton{}
+	names := make(map[string]dfa.State, len(states))
+	used := make(map[dfa.State]bool, len(states))
+	for _, s := range states {
+		if s.ID == "" {
+			return nil, errors.New("JFLAP state has no id")
+		}
+		if _, ok := names[s.ID]; ok {
+			return nil, errors.Errorf("JFLAP state id '%s' is repeated", s.ID)
+		}
+		name := dfa.State(s.Name)
+		if name == "" {
+			name = dfa.State(s.ID)
+		}
+		if used[name] {
+			return nil, errors.Errorf("state name '%s' is repeated", name)
+		}
+		names[s.ID] = name
+		used[name] = true
+		a.states = append(a.states, name)
+
+		if s.Initial != nil {
+			if a.start != "" {
+				return nil, errors.Errorf(
+					"states '%s' and '%s' are both initial", a.start, name,
+				)
+			}
+			a.start = name
+		}
+		if s.Final != nil {
+			a.finals = append(a.finals, name)
+		}
+	}
+
+	chain := make(map[chainKey]dfa.State)
+	for _, t := range transitions {
+		from, ok := names[t.From]
+		if !ok {
+			return nil, errors.Errorf(
+				"transition from unknown state id '%s'", t.From,
+			)
+		}
+		to, ok := names[t.To]
+		if !ok {
+			return nil, errors.Errorf(
+				"transition to unknown state id '%s'", t.To,
+			)
+		}
+		a.addRead(from, to, t.Read, chain, used)
+	}
+	return a, nil
+}
+
+// chainKey identifies state reached after reading prefix of transition label
+type chainKey struct {
+	from   dfa.State
+	prefix string
+}
+
+// addRead adds transition that reads characters of label one by one.
+// Transitions from the same state share states for common prefixes of their
+// labels, which does not change the language
+func (a *automaton) addRead(
+	from, to dfa.State,
+	label string,
+	chain map[chainKey]dfa.State,
+	used map[dfa.State]bool,
+) {
+	chars := []rune(label)
+	if len(chars) == 0 {
+		a.transitions = append(a.transitions, transition{from: from, to: to})
+		return
+	}
+
+	current := from
+	for i, r := range chars {
+		l := dfa.Letter(string(r))
+		a.addLetter(l)
+		if i == len(chars)-1 {
+			a.transitions = append(a.transitions, transition{
+				from: current, to: to, letter: l,
+			})
+			return
+		}
+
+		key := chainKey{from: from, prefix: string(chars[:i+1])}
+		next, ok := chain[key]
+		if !ok {
+			next = dfa.State(string(from) + "." + key.prefix)
+			for used[next] {
+				next += "'"
+			}
+			used[next] = true
+			chain[key] = next
+			a.states = append(a.states, next)
+			a.transitions = append(a.transitions, transition{
+				from: current, to: next, letter: l,
+			})
+		}
+		current = next
+	}
+}
+
+// WriteJFLAP writes DFA as JFLAP file. States are numbered in the order of
+// Compile and placed on a circle, transitions are sorted by state and letter.
+// JFLAP reads letters of several characters as several letters, so DFA with
+// such letters can not be written
+func WriteJFLAP(w io.Writer, m *dfa.DFA) error {
+	c := m.Compile()
+	n := c.NumStates()
+	for _, l := range c.Letters() {
+		if utf8.RuneCountInString(string(l)) > 1 {
+			return errors.Errorf(
+				"letter '%s' has several characters, JFLAP would read them "+
+					"as several letters", l,
+			)
+		}
+	}
+
+	// keep neighbouring states about 100 points apart
+	radius := math.Max(100, 100*float64(n)/(2*math.Pi))
+	a := &jflapAutomaton{
+		States: make([]jflapState, 0, n),
+	}
+	for s := 0; s < n; s++ {
+		angle := 2 * math.Pi * float64(s) / float64(n)
+		state := jflapState{
+			ID:   strconv.Itoa(s),
+			Name: string(c.State(s)),
+			X:    math.Round(radius + 50 + radius*math.Cos(angle)),
+			Y:    math.Round(radius + 50 + radius*math.Sin(angle)),
+		}
+		if s == c.Start() {
+			state.Initial = &struct{}{}
+		}
+		if c.IsFinal(s) {
+			state.Final = &struct{}{}
+		}
+		a.States = append(a.States, state)
+	}
+
+	for s := 0; s < n; s++ {
+		for l := 0; l < c.NumLetters(); l++ {
+			to := c.Next(s, l)
+			if to < 0 {
+				continue
+			}
+			a.Transitions = append(a.Transitions, jflapTransition{
+				From: strconv.Itoa(s),
+				To:   strconv.Itoa(to),
+				Read: string(c.Letter(l)),
+			})
+		}
+	}
+
+	if _, err := io.WriteString(w, jflapHeader); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	err := enc.Encode(jflapStructure{Type: jflapTypeFA, Automaton: a})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
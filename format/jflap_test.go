@@ -0,0 +1,192 @@
+package format
+
+import (
+	"context"
+	"dfa-grader/dfa"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func randomDFA(r *rand.Rand, n int, letters []dfa.Letter) *dfa.DFA {
+	m := dfa.New()
+	for _, l := range letters {
+		m.SetLetter(l)
+	}
+	var finals []dfa.State
+	for s := 0; s < n; s++ {
+		from := dfa.State(fmt.Sprint("s", s))
+		m.SetState(from)
+		for _, l := range letters {
+			if r.Intn(5) > 0 {
+				to := dfa.State(fmt.Sprint("s", r.Intn(n)))
+				m.SetTransition(from, l, to) // nolint: errcheck
+			}
+		}
+		if r.Intn(3) == 0 {
+			finals = append(finals, from)
+		}
+	}
+	m.SetStartState("s0")
+	m.SetFinalStates(finals...)
+	return m
+}
+
+func TestJFLAPRoundTrip(t *testing.T) {
+	// letters and names that need escaping in XML
+	letters := []dfa.Letter{"a", "<", "&", `"`}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		m := randomDFA(r, 1+r.Intn(6), letters)
+		m.SetTransition(`<q&"0>`, "a", "s0") // nolint: errcheck
+
+		var b strings.Builder
+		if err := WriteJFLAP(&b, m); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(b.String(), "&lt;q&amp;") {
+			t.Fatalf("names are not escaped\n%s", b.String())
+		}
+		back, err := ReadJFLAP(strings.NewReader(b.String()))
+		if err != nil {
+			t.Fatalf("%v\n%s", err, b.String())
+		}
+		// letters without transitions are not in the file
+		for _, l := range m.Alphabet() {
+			back.SetLetter(l)
+		}
+		if eq, _ := dfa.Compare(context.Background(), m, back); !eq {
+			t.Fatalf("language changed\n%s", b.String())
+		}
+		if len(back.States()) != len(m.States()) || !back.HasState(`<q&"0>`) {
+			t.Fatalf("got states %v, want %v", back.States(), m.States())
+		}
+	}
+
+	m := dfa.New()
+	m.SetTransition("p", "ab", "q") // nolint: errcheck
+	m.SetStartState("p")
+	err := WriteJFLAP(&strings.Builder{}, m)
+	if err == nil || !strings.Contains(err.Error(), "'ab' has several characters") {
+		t.Errorf("got error %v for letter of several characters", err)
+	}
+}
+
+// jflapFile wraps states and transitions into JFLAP 7 file
+func jflapFile(body string) string {
+	return `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<structure><type>fa</type><automaton>` + body + `</automaton></structure>`
+}
+
+func TestReadJFLAP(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		states   []dfa.State
+		accepted []dfa.Word
+		rejected []dfa.Word
+	}{
+		{
+			// labels of several characters are split into chains of
+			// transitions that share common prefixes
+			name: "multi-character labels",
+			src: jflapFile(`
+				<state id="0" name="q0"><initial/></state>
+				<state id="1" name="q1"><final/></state>
+				<state id="2" name="q0.a"/>
+				<transition><from>0</from><to>1</to><read>ab</read></transition>
+				<transition><from>0</from><to>1</to><read>ac</read></transition>
+				<transition><from>1</from><to>1</to><read>abc</read></transition>`),
+			states:   []dfa.State{"q0", "q1", "q0.a", "q0.a'", "q1.a", "q1.ab"},
+			accepted: []dfa.Word{{"a", "b"}, {"a", "c"}, {"a", "b", "a", "b", "c"}},
+			rejected: []dfa.Word{{}, {"a"}, {"a", "b", "c"}, {"a", "b", "a", "b"}},
+		},
+		{
+			name: "lambda transitions",
+			src: jflapFile(`
+				<state id="0" name="q0"><initial/></state>
+				<state id="1" name="q1"/>
+				<state id="2" name="q2"><final/></state>
+				<transition><from>0</from><to>1</to><read/></transition>
+				<transition><from>0</from><to>0</to><read>b</read></transition>
+				<transition><from>1</from><to>2</to><read>a</read></transition>`),
+			accepted: []dfa.Word{{"a"}, {"b", "a"}, {"b", "b", "a"}},
+			rejected: []dfa.Word{{}, {"b"}, {"a", "a"}, {"a", "b"}},
+		},
+		{
+			name: "JFLAP 6 without automaton element",
+			src: `<structure><type>fa</type>
+				<state id="0"><initial/><final/></state>
+				<transition><from>0</from><to>0</to><read>&lt;</read></transition>
+				</structure>`,
+			states:   []dfa.State{"0"},
+			accepted: []dfa.Word{{}, {"<", "<"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ReadJFLAP(strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.states != nil && len(m.States()) != len(tt.states) {
+				t.Fatalf("got states %v, want %v", m.States(), tt.states)
+			}
+			for _, s := range tt.states {
+				if !m.HasState(s) {
+					t.Errorf("got states %v, want %v", m.States(), tt.states)
+				}
+			}
+			for _, w := range tt.accepted {
+				if !m.Accepts(w) {
+					t.Errorf("%q is rejected", w.Strings())
+				}
+			}
+			for _, w := range tt.rejected {
+				if m.Accepts(w) {
+					t.Errorf("%q is accepted", w.Strings())
+				}
+			}
+		})
+	}
+
+	n, err := ReadJFLAPNFA(strings.NewReader(tests[1].src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !n.HasState("q1") {
+		t.Error("NFA lacks state of lambda transition")
+	}
+}
+
+func TestReadJFLAPErrors(t *testing.T) {
+	tests := []struct {
+		src     string
+		message string
+	}{
+		{"<structure>", "could not parse JFLAP file"},
+		{"<structure><type>pda</type></structure>", "type should be 'fa', got 'pda'"},
+		{jflapFile(`<state name="q"/>`), "state has no id"},
+		{jflapFile(`<state id="0"/><state id="0"/>`), "id '0' is repeated"},
+		{jflapFile(`<state id="0" name="q"/><state id="1" name="q"/>`), "name 'q' is repeated"},
+		{
+			jflapFile(`<state id="0"><initial/></state><state id="1"><initial/></state>`),
+			"states '0' and '1' are both initial",
+		},
+		{
+			jflapFile(`<state id="0"/><transition><from>1</from><to>0</to></transition>`),
+			"transition from unknown state id '1'",
+		},
+		{
+			jflapFile(`<state id="0"/><transition><from>0</from><to>1</to></transition>`),
+			"transition to unknown state id '1'",
+		},
+	}
+	for _, tt := range tests {
+		_, err := ReadJFLAP(strings.NewReader(tt.src))
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("%s: got error %v, want %q", tt.src, err, tt.message)
+		}
+	}
+}
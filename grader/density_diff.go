@@ -0,0 +1,253 @@
+package grader
+
+import (
+	"context"
+	"dfa-grader/config"
+	"dfa-grader/dfa"
+	"fmt"
+	"math"
+)
+
+// densityChain is a random walk on product automaton where every letter is
+// read with equal probability. Share of words of length n that are accepted
+// by exactly one automaton equals probability that the walk is in a
+// differing pair after n steps
+type densityChain struct {
+	p *dfa.Product
+	k float64
+}
+
+// components finds strongly connected components of the product using
+// Tarjan's algorithm, component ids are returned for every pair
+func (c *densityChain) components() ([]int, int) {
+	n := c.p.Size()
+	index := make([]int, n)
+	low := make([]int, n)
+	onStack := make([]bool, n)
+	comp := make([]int, n)
+	for i := range index {
+		index[i] = -1
+	}
+	var stack []int
+	var counter, count int
+
+	var visit func(v int)
+	visit = func(v int) {
+		index[v] = counter
+		low[v] = counter
+		counter++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range c.p.Successors(v) {
+			if index[w] == -1 {
+				visit(w)
+				if low[w] < low[v] {
+					low[v] = low[w]
+				}
+			} else if onStack[w] && index[w] < low[v] {
+				low[v] = index[w]
+			}
+		}
+
+		if low[v] == index[v] {
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				comp[w] = count
+				if w == v {
+					break
+				}
+			}
+			count++
+		}
+	}
+	for v := 0; v < n; v++ {
+		if index[v] == -1 {
+			visit(v)
+		}
+	}
+	return comp, count
+}
+
+// bottomDensity calculates share of differing pairs in stationary
+// distribution of a closed component
+func (c *densityChain) bottomDensity(
+	ctx context.Context,
+	members []int,
+) (float64, error) {
+	n := len(members)
+	pos := make(map[int]int, n)
+	for i, v := range members {
+		pos[v] = i
+	}
+
+	// pi * (P - I) = 0 written as (P - I)^T * pi = 0, with the last equation
+	// replaced by sum(pi) = 1
+	a := make([][]float64, n)
+	for i := range a {
+		a[i] = make([]float64, n+1)
+	}
+	for i, v := range members {
+		a[i][i]--
+		for _, w := range c.p.Successors(v) {
+			a[pos[w]][i] += 1 / c.k
+		}
+	}
+	for j := 0; j <= n; j++ {
+		a[n-1][j] = 1
+	}
+
+	pi, err := solveLinear(ctx, a)
+	if err != nil {
+		return 0, err
+	}
+	var density float64
+	for i, v := range members {
+		if c.p.Differs(v) {
+			density += pi[i]
+		}
+	}
+	return density, nil
+}
+
+// density calculates limiting share of words accepted by exactly one of
+// automata. Walk eventually ends up in one of closed components, so the
+// result is the average over closed components weighted by probability of
+// reaching them. Calculation stops when ctx is done
+func (c *densityChain) density(ctx context.Context) (float64, error) {
+	comp, count := c.components()
+	members := make([][]int, count)
+	closed := make([]bool, count)
+	for i := range closed {
+		closed[i] = true
+	}
+	for v := 0; v < c.p.Size(); v++ {
+		members[comp[v]] = append(members[comp[v]], v)
+		for _, w := range c.p.Successors(v) {
+			if comp[w] != comp[v] {
+				closed[comp[v]] = false
+			}
+		}
+	}
+
+	value := make([]float64, c.p.Size())
+	var transient []int
+	for id, vs := range members {
+		if !closed[id] {
+			transient = append(transient, vs...)
+			continue
+		}
+		d, err := c.bottomDensity(ctx, vs)
+		if err != nil {
+			return 0, err
+		}
+		for _, v := range vs {
+			value[v] = d
+		}
+	}
+	if len(transient) == 0 {
+		return value[0], nil
+	}
+
+	// value of transient pair is the average of values of its successors
+	pos := make(map[int]int, len(transient))
+	for i, v := range transient {
+		pos[v] = i
+	}
+	n := len(transient)
+	a := make([][]float64, n)
+	for i, v := range transient {
+		a[i] = make([]float64, n+1)
+		a[i][i] = 1
+		for _, w := range c.p.Successors(v) {
+			if j, ok := pos[w]; ok {
+				a[i][j] -= 1 / c.k
+			} else {
+				a[i][n] += value[w] / c.k
+			}
+		}
+	}
+	x, err := solveLinear(ctx, a)
+	if err != nil {
+		return 0, err
+	}
+	for i, v := range transient {
+		value[v] = x[i]
+	}
+	return value[0], nil
+}
+
+// solveLinear solves system given as augmented matrix using Gaussian
+// elimination with partial pivoting. Elimination takes cubic time, so it
+// stops when ctx is done
+func solveLinear(ctx context.Context, a [][]float64) ([]float64, error) {
+	n := len(a)
+	for col := 0; col < n; col++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		if a[col][col] == 0 {
+			continue
+		}
+		for row := 0; row < n; row++ {
+			if row == col || a[row][col] == 0 {
+				continue
+			}
+			factor := a[row][col] / a[col][col]
+			for j := col; j <= n; j++ {
+				a[row][j] -= factor * a[col][j]
+			}
+		}
+	}
+
+	x := make([]float64, n)
+	for i := range x {
+		if a[i][i] != 0 {
+			x[i] = a[i][n] / a[i][i]
+		}
+	}
+	return x, nil
+}
+
+// GetDensityDifference calculates score from asymptotic density of words
+// that are accepted by exactly one automaton, i.e. the limit of the share of
+// such words among all words of length n. Limit is taken in Cesaro sense, so
+// it exists for periodic automata as well
+// m2 is automata that is expected to be received
+// function returns result in scale from 0 to 1
+// Calculation stops when ctx is done or density diff timeout passes, then
+// score is 0 and returned stats report it. Timeout is not applied in
+// deterministic mode
+func GetDensityDifference(
+	ctx context.Context,
+	m1, m2 *dfa.DFA,
+) (float64, Stats) {
+	ctx, cancel := withTimeout(ctx, config.DensityDiff.Timeout)
+	defer cancel()
+
+	stats := Stats{Completed: true, CompletedDepth: -1}
+	p := dfa.NewProduct(m1, m2)
+	chain := &densityChain{p: p, k: float64(len(p.Successors(0)))}
+	if chain.k == 0 {
+		return 1.0, stats
+	}
+
+	density, err := chain.density(ctx)
+	if err != nil {
+		fmt.Println("Density diff: stopped,", err.Error())
+		stats.stopped(ctx)
+		return 0.0, stats
+	}
+	fmt.Printf("Density diff: density %f\n", density)
+
+	return math.Max(0.0, math.Min(1.0, 1-density)), stats
+}
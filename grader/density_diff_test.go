@@ -0,0 +1,120 @@
+package grader
+
+import (
+	"context"
+	"dfa-grader/config"
+	"dfa-grader/dfa"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func setupDensityDiff(t *testing.T) {
+	t.Helper()
+	if err := config.Read(""); err != nil {
+		t.Fatal(err)
+	}
+	config.Deterministic = true
+}
+
+func TestGetDensityDifference(t *testing.T) {
+	setupDensityDiff(t)
+
+	// empty language over {a, b}
+	empty := []string{"p a p", "p b p"}
+	tests := []struct {
+		name    string
+		attempt []string
+		target  []string
+		score   float64
+	}{
+		{"both empty", empty, empty, 1},
+		{"universal", []string{"*p a *p", "*p b *p"}, empty, 0},
+		{"even length", []string{"*e a o", "*e b o", "o a *e", "o b *e"}, empty, 0.5},
+		{"even number of a's", []string{"*e a o", "*e b *e", "o a *e", "o b o"}, empty, 0.5},
+		{"starting with a", []string{"p a *q", "p b r", "*q a *q", "*q b *q"}, empty, 0.5},
+		{"containing a", []string{"p a *q", "p b p", "*q a *q", "*q b *q"}, empty, 0},
+		{"single word", []string{"p a *q", "p b p"}, empty, 1},
+		{
+			"words ending with a and b",
+			[]string{"p a *q", "p b p", "*q a *q", "*q b p"},
+			[]string{"*p a q", "*p b *p", "q a q", "q b *p"},
+			0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m1, m2 := readDFA(t, tt.attempt...), readDFA(t, tt.target...)
+			score, stats := GetDensityDifference(context.Background(), m1, m2)
+			if !stats.Completed {
+				t.Fatalf("calculation did not complete: %+v", stats)
+			}
+			if math.Abs(score-tt.score) > 1e-9 {
+				t.Errorf("score %f, want %f", score, tt.score)
+			}
+		})
+	}
+}
+
+// TestGetDensityDifferenceConvergence compares density with average share
+// of differing words of lengths up to n, which converges to it as O(1/n)
+func TestGetDensityDifferenceConvergence(t *testing.T) {
+	setupDensityDiff(t)
+
+	const n = 400
+	r := rand.New(rand.NewSource(1))
+	alphabet := []dfa.Letter{"a", "b"}
+	random := func(states int) *dfa.DFA {
+		m := dfa.New()
+		var finals []dfa.State
+		for s := 0; s < states; s++ {
+			from := dfa.State(fmt.Sprint("q", s))
+			for _, l := range alphabet {
+				to := dfa.State(fmt.Sprint("q", r.Intn(states)))
+				m.SetTransition(from, l, to) // nolint: errcheck
+			}
+			if r.Intn(2) == 0 {
+				finals = append(finals, from)
+			}
+		}
+		m.SetStartState("q0")
+		m.SetFinalStates(finals...)
+		return m
+	}
+
+	for i := 0; i < 30; i++ {
+		m1, m2 := random(1+r.Intn(5)), random(1+r.Intn(5))
+		score, _ := GetDensityDifference(context.Background(), m1, m2)
+
+		counter := dfa.NewWordCounter(m1, m2)
+		var average float64
+		for length := 0; length < n; length++ {
+			share, _ := new(big.Rat).SetFrac(
+				counter.Differing(), counter.Words(),
+			).Float64()
+			average += share / n
+			counter.Next()
+		}
+		if math.Abs(1-score-average) > 0.05 {
+			t.Errorf(
+				"pair %d: density %f, average share up to length %d is %f",
+				i, 1-score, n, average,
+			)
+		}
+	}
+}
+
+func TestGetDensityDifferenceCancelled(t *testing.T) {
+	setupDensityDiff(t)
+
+	m1 := readDFA(t, "*e a o", "*e b o", "o a *e", "o b *e")
+	m2 := readDFA(t, "p a p", "p b p")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	score, stats := GetDensityDifference(ctx, m1, m2)
+	if score != 0 || stats.Completed || stats.TimedOut {
+		t.Errorf("got score %f and stats %+v after cancellation", score, stats)
+	}
+}
@@ -1,275 +1,414 @@
 package grader
 
 import (
+	"context"
 	"dfa-grader/config"
 	"dfa-grader/dfa"
+	"errors"
 	"fmt"
-	"strings"
+	"runtime"
 	"sync"
-	"time"
+	"sync/atomic"
 )
 
-type dfaSyntaxSolver struct {
-	progress  []*sync.WaitGroup
-	mu        *sync.Mutex
-	solution  *int
-	timeouted chan struct{}
-}
+// EditKind describes type of single edit in DFA syntax diff
+type EditKind int
 
-func newDFASyntaxSolver(depth int, m *dfa.DFA) *dfaSyntaxSolver {
-	wgs := []*sync.WaitGroup{}
-	for i := 0; i <= depth+1; i++ {
-		wgs = append(wgs, &sync.WaitGroup{})
-	}
-	worst := new(int)
-	*worst = len(m.States()) * len(m.Alphabet())
-	if *worst <= config.DFADiff.MaxDepth {
-		*worst = config.DFADiff.MaxDepth + 10
-	}
-	return &dfaSyntaxSolver{
-		mu:        &sync.Mutex{},
-		progress:  wgs,
-		solution:  worst,
-		timeouted: make(chan struct{}),
-	}
+// Edits that syntax diff may apply to attempted automaton
+const (
+	EditAddState EditKind = iota
+	EditChangeStart
+	EditToggleFinal
+	EditRedirect
+)
+
+// Edit is a single modification of attempted automaton
+type Edit struct {
+	Kind EditKind
+	// State is added state, new start state, toggled state or state from
+	// which redirected transition starts
+	State  dfa.State
+	Letter dfa.Letter // letter of redirected transition
+	To     dfa.State  // new target of redirected transition
+	Final  bool       // whether toggled state becomes accepting
 }
 
-func (solver *dfaSyntaxSolver) checkEq(
-	m1, m2 *dfa.DFA,
-	depth int,
-) bool {
-	// check if m1 == m2
-	eq, err := dfa.Compare(m1, m2)
-	if err != nil {
-		fmt.Println("Could not minimize automata, aborting calculation")
-		return false
-	}
-	if eq {
-		solver.mu.Lock()
-		if *solver.solution > depth {
-			*solver.solution = depth
+func (e Edit) String() string {
+	switch e.Kind {
+	case EditAddState:
+		return fmt.Sprintf("add state %v", e.State)
+	case EditChangeStart:
+		return fmt.Sprintf("make state %v the start state", e.State)
+	case EditToggleFinal:
+		if e.Final {
+			return fmt.Sprintf("make state %v accepting", e.State)
 		}
-		solver.mu.Unlock()
+		return fmt.Sprintf("make state %v non-accepting", e.State)
+	case EditRedirect:
+		return fmt.Sprintf("redirect (%v,%v) to %v", e.State, e.Letter, e.To)
 	}
-	return eq
+	return "unknown edit"
 }
 
-type domainElement struct {
-	l dfa.Letter
-	s dfa.State
+// withEdit returns new edit path, so that paths of parallel branches do not
+// share memory
+func withEdit(path []Edit, e Edit) []Edit {
+	result := make([]Edit, len(path), len(path)+1)
+	copy(result, path)
+	return append(result, e)
 }
 
-func (solver *dfaSyntaxSolver) getEditCount(
-	m1, m2 *dfa.DFA,
-	depth int,
-	state, start, final, transition bool,
-	lastEdit interface{},
-) {
-	defer solver.progress[depth].Done()
-	select {
-	case <-solver.timeouted:
-		return
-	default:
-	}
+// searchNode is an automaton obtained from the attempt by applying path
+type searchNode struct {
+	m    *dfa.Compiled
+	path []Edit
+}
 
-	if depth > config.DFADiff.MaxDepth || depth > *solver.solution {
-		return
+// onlyAdded checks if all edits so far added new states. New states are
+// added before any other edits, so that other edits may use them
+func (n *searchNode) onlyAdded() bool {
+	for _, e := range n.path {
+		if e.Kind != EditAddState {
+			return false
+		}
 	}
+	return true
+}
 
-	// check if m1 == m2
-	if solver.checkEq(m1, m2, depth) {
-		return
-	}
+// dfaSyntaxSolver searches for the shortest sequence of edits that makes
+// attempted automaton equivalent to the target. Search proceeds depth by
+// depth, all nodes of one depth are processed by a pool of workers, so the
+// first solution found is the shortest one.
+//
+// Edits on distinct parts of automaton commute, so for any counterexample
+// word one of the remaining edits must change either the run of automaton on
+// that word or acceptance of the last state of the run, and that edit can
+// be applied first. Nodes are expanded only with such edits, which keeps the
+// branching factor proportional to the length of the shortest counterexample
+// rather than to the size of transition table.
+//
+// Automata are compiled, so states are always tried in the order of their
+// numbering, except that states agreeing with the seed mapping go first.
+// Nodes of one depth are collected in the order of their parents, so the
+// result does not depend on map ordering or on scheduling of workers
+type dfaSyntaxSolver struct {
+	target     *dfa.Compiled // minimized target
+	targetSize int
+	maxDepth   int
+	maxNodes   int // limit of nodes to check, 0 means no limit
+	workers    int
 
-	if state {
-		solver.tryAddState(m1, m2, depth, lastEdit)
-	}
+	// mapping pairs states of the attempt with corresponding target states
+	// where automata agree, inverse pairs target states with attempt states
+	mapping, inverse []int
 
-	// try different start states
-	if start {
-		solver.tryChangeStart(m1, m2, depth, lastEdit)
+	// visited holds canonical keys of checked automata, so automata reached
+	// by applying same edits in different order or isomorphic automata with
+	// differently numbered states are checked only once
+	visited map[string]bool
+	ctx     context.Context
 
-		lastEdit = dfa.State("")
-	}
+	examined       int64 // nodes checked, updated atomically
+	completedDepth int
+}
 
-	// try swapping one state final/non-final
-	if final {
-		solver.tryChangeFinal(m1, m2, depth, lastEdit)
+// errOutOfBudget is returned by search when checking the next depth would
+// exceed node budget
+var errOutOfBudget = errors.New("node budget exhausted")
+
+func newDFASyntaxSolver(
+	ctx context.Context,
+	maxDepth, maxNodes int,
+	target *dfa.Compiled,
+) *dfaSyntaxSolver {
+	return &dfaSyntaxSolver{
+		target:     target,
+		targetSize: target.NumStates(),
+		maxDepth:   maxDepth,
+		maxNodes:   maxNodes,
+		workers:    runtime.NumCPU(),
+		visited:    make(map[string]bool),
+		ctx:        ctx,
 
-		lastEdit = domainElement{s: "", l: ""}
+		completedDepth: -1,
 	}
+}
 
-	// try switching transition
-	if transition {
-		solver.tryChangeTransition(m1, m2, depth, lastEdit)
+// seed finds partial mapping of attempt states to target states, edits that
+// agree with it are tried first, so found edits keep parts of the attempt
+// that already match the target
+func (solver *dfaSyntaxSolver) seed(attempt *dfa.Compiled) {
+	solver.mapping, _ = attempt.Isomorphism(solver.target)
+	solver.inverse = make([]int, solver.targetSize)
+	for t := range solver.inverse {
+		solver.inverse[t] = -1
+	}
+	for s, t := range solver.mapping {
+		if t >= 0 {
+			solver.inverse[t] = s
+		}
 	}
 }
 
-func (solver *dfaSyntaxSolver) tryAddState(
-	m1, m2 *dfa.DFA,
-	depth int,
-	lastEdit interface{},
-) {
-	// add new state
-	// assume that syntax mistakes do not exceed single missing state
-	m1Copy := m1.Copy()
-	s := m1Copy.GetNewState()
-	for _, l := range m1Copy.Alphabet() {
-		m1Copy.SetTransition(s, l, s) // nolint: errcheck,gas
+// preferred returns attempt state that corresponds to target state reached
+// from the state paired with s with letter a, s is -1 for the start state.
+// Result is -1 if there is no such state
+func (solver *dfaSyntaxSolver) preferred(m *dfa.Compiled, s, a int) int {
+	if s < 0 {
+		return solver.inverse[solver.target.Start()]
 	}
-	solver.progress[depth+1].Add(1)
-	go solver.getEditCount(
-		m1Copy, m2,
-		depth+1,
-		true, true, true, true,
-		lastEdit,
-	)
+	if s >= len(solver.mapping) || solver.mapping[s] < 0 {
+		return -1
+	}
+	b, ok := solver.target.LetterIndex(m.Letter(a))
+	if !ok {
+		return -1
+	}
+	t := solver.target.Next(solver.mapping[s], b)
+	if t < 0 {
+		return -1
+	}
+	return solver.inverse[t]
 }
 
-func (solver *dfaSyntaxSolver) tryChangeStart(
-	m1, m2 *dfa.DFA,
-	depth int,
-	lastEdit interface{},
-) {
-	for _, s := range m1.States() {
-		if strings.Compare(string(lastEdit.(dfa.State)), string(s)) == 1 {
-			continue
+// candidates lists states of m with preferred state first
+func candidates(m *dfa.Compiled, preferred int) []int {
+	result := make([]int, 0, m.NumStates())
+	if preferred >= 0 {
+		result = append(result, preferred)
+	}
+	for s := 0; s < m.NumStates(); s++ {
+		if s != preferred {
+			result = append(result, s)
 		}
+	}
+	return result
+}
 
-		m1Copy := m1.Copy()
-		m1Copy.SetStartState(s)
-		solver.progress[depth+1].Add(1)
-		go solver.getEditCount(
-			m1Copy, m2,
-			depth+1,
-			false, true, true, true,
-			s,
-		)
+// lowerBound estimates how many edits are still needed for the automaton.
+// Automaton equivalent to the target has at least as many states as the
+// minimized target once its missing transitions lead to a sink. Each edit
+// adds at most one state to the completed automaton, so at least the
+// missing states have to be added. The attempt itself is not minimized, a
+// single edit may grow its minimal automaton by many states
+func (solver *dfaSyntaxSolver) lowerBound(m *dfa.Compiled) int {
+	missing := solver.targetSize - m.Complete().NumStates()
+	if missing < 0 {
+		return 0
 	}
+	return missing
 }
 
-func (solver *dfaSyntaxSolver) tryChangeFinal(
-	m1, m2 *dfa.DFA,
-	depth int,
-	lastEdit interface{},
-) {
-	for _, s := range m1.States() {
-		if strings.Compare(string(lastEdit.(dfa.State)), string(s)) == 1 {
-			continue
+// parallel runs fn for indexes from 0 to n-1 using a pool of workers,
+// remaining indexes are skipped once solver's context is done
+func (solver *dfaSyntaxSolver) parallel(n int, fn func(idx int)) {
+	var next int64 = -1
+	wg := &sync.WaitGroup{}
+	for w := 0; w < solver.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				idx := int(atomic.AddInt64(&next, 1))
+				if idx >= n {
+					return
+				}
+				if solver.ctx.Err() != nil {
+					return
+				}
+				fn(idx)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// search returns the shortest edit path, second return value is false if no
+// path was found within maximum depth. Error is returned if solver's
+// context is done before search finishes, or errOutOfBudget if the next
+// depth has more nodes than budget allows. Budget is checked for whole
+// depths, so the same nodes are checked whenever it runs out
+func (solver *dfaSyntaxSolver) search(m *dfa.Compiled) ([]Edit, bool, error) {
+	frontier := []*searchNode{{m: m}}
+	solver.visited[m.CanonicalKey()] = true
+
+	for depth := 0; depth <= solver.maxDepth; depth++ {
+		if solver.maxNodes > 0 &&
+			int(solver.examined)+len(frontier) > solver.maxNodes {
+			return nil, false, errOutOfBudget
 		}
 
-		m1Copy := m1.Copy()
-		finalStates := m1Copy.FinalStates()
-		var wasFinal bool
-		for idx, f := range finalStates {
-			// need loop if we want to remove from final states
-			if f == s {
-				finalStates = append(
-					finalStates[:idx],
-					finalStates[idx+1:]...,
-				)
-				wasFinal = true
-				break
+		var found int32
+		solved := make([]bool, len(frontier))
+		children := make([][]*searchNode, len(frontier))
+		solver.parallel(len(frontier), func(idx int) {
+			node := frontier[idx]
+			atomic.AddInt64(&solver.examined, 1)
+			word, differ := node.m.DistinguishingWord(solver.target)
+			if !differ {
+				solved[idx] = true
+				atomic.StoreInt32(&found, 1)
+				return
+			}
+			if depth == solver.maxDepth || atomic.LoadInt32(&found) == 1 {
+				return
 			}
+			children[idx] = solver.expand(node, word)
+		})
+
+		if err := solver.ctx.Err(); err != nil {
+			return nil, false, err
 		}
-		if !wasFinal {
-			finalStates = append(finalStates, s)
+		solver.completedDepth = depth
+
+		// prefer the first solution in frontier order
+		for idx, ok := range solved {
+			if ok {
+				return frontier[idx].path, true, nil
+			}
 		}
-		m1Copy.SetFinalStates(finalStates...)
-		solver.progress[depth+1].Add(1)
-		go solver.getEditCount(
-			m1Copy, m2,
-			depth+1,
-			false, false, true, true,
-			s,
-		)
-	}
-}
 
-func (solver *dfaSyntaxSolver) tryChangeTransition(
-	m1, m2 *dfa.DFA,
-	depth int,
-	lastEdit interface{},
-) {
-	for _, from := range m1.States() {
-		for _, to := range m1.States() {
-			for _, l := range m1.Alphabet() {
-				// greedy
-				de := lastEdit.(domainElement)
-				if strings.Compare(string(de.s), string(from)) == 1 {
+		next := []*searchNode{}
+		for _, nodes := range children {
+			for _, child := range nodes {
+				if depth+1+solver.lowerBound(child.m) > solver.maxDepth {
 					continue
 				}
-				if strings.Compare(string(de.s), string(from)) == 0 &&
-					strings.Compare(string(de.l), string(l)) == 1 {
+				key := child.m.CanonicalKey()
+				if solver.visited[key] {
 					continue
 				}
-				m1Copy := m1.Copy()
-				m1Copy.SetTransition(from, l, to) // nolint: errcheck,gas
-				solver.progress[depth+1].Add(1)
-				go solver.getEditCount(
-					m1Copy, m2,
-					depth+1,
-					false, false, false, true,
-					domainElement{s: from, l: l},
-				)
+				solver.visited[key] = true
+				next = append(next, child)
 			}
 		}
+		fmt.Printf(
+			"Syntax Diff: Done all edits of size %d, %d to check next\n",
+			depth, len(next),
+		)
+		frontier = next
 	}
+
+	return nil, false, nil
 }
 
-// GetDFASyntaxDifference calculates score by measuring amount of edits
-// necessary to transform one dfa into the other
-// m2 is automata that is expected to be received
-// function returns result in scale from 0 to 1
-func GetDFASyntaxDifference(m1, m2 *dfa.DFA) float64 {
-	solver := newDFASyntaxSolver(config.DFADiff.MaxDepth, m1)
+// expand creates nodes for edits that change the outcome of automaton on
+// given counterexample word
+func (solver *dfaSyntaxSolver) expand(
+	node *searchNode,
+	word dfa.Word,
+) []*searchNode {
+	m := node.m
+	var result []*searchNode
+	child := func(e Edit, c *dfa.Compiled) {
+		result = append(result, &searchNode{m: c, path: withEdit(node.path, e)})
+	}
 
-	noResultScore := *solver.solution
+	// add new state, which is only useful if later edits lead to it
+	if node.onlyAdded() {
+		s := m.NewStateName()
+		child(Edit{Kind: EditAddState, State: s}, m.WithState(s))
+	}
 
-	go func() {
-		time.Sleep(config.DFADiff.Timeout)
-		fmt.Println("Syntax diff: Timeouted")
-		close(solver.timeouted)
-	}()
+	for _, s := range candidates(m, solver.preferred(m, -1, -1)) {
+		if s == m.Start() {
+			continue
+		}
+		child(Edit{Kind: EditChangeStart, State: m.State(s)}, m.WithStart(s))
+	}
 
-	m2Min := m2.Copy()
-	err := m2Min.Determinize()
-	if err != nil {
-		return 0.0
+	// follow the run on counterexample word, if it stops early, the missing
+	// transition has to be added
+	run := m.Walk(word)
+	if len(run) == len(word)+1 {
+		last := run[len(run)-1]
+		final := !m.IsFinal(last)
+		child(
+			Edit{Kind: EditToggleFinal, State: m.State(last), Final: final},
+			m.WithFinal(last, final),
+		)
 	}
-	m2Min.Minimize()
-
-	solver.progress[0].Add(1)
-	go solver.getEditCount(
-		m1, m2Min,
-		0,
-		true, true, true, true,
-		dfa.State(""),
-	)
 
-	haveResult := make(chan struct{}, 1)
-	go func() {
-		for i := 0; i < config.DFADiff.MaxDepth+1; i++ {
-			solver.progress[i].Wait()
-			fmt.Printf("Syntax Diff: Done all edits of size %d\n", i)
+	redirected := make(map[[2]int]bool)
+	for idx := 0; idx < len(run) && idx < len(word); idx++ {
+		a, ok := m.LetterIndex(word[idx])
+		if !ok {
+			break
 		}
-		haveResult <- struct{}{}
-	}()
+		from, current := run[idx], m.Next(run[idx], a)
+		if redirected[[2]int{from, a}] {
+			continue
+		}
+		redirected[[2]int{from, a}] = true
+		for _, to := range candidates(m, solver.preferred(m, from, a)) {
+			if to == current {
+				continue
+			}
+			e := Edit{
+				Kind:   EditRedirect,
+				State:  m.State(from),
+				Letter: word[idx],
+				To:     m.State(to),
+			}
+			child(e, m.WithTransition(from, a, to))
+		}
+	}
+
+	return result
+}
+
+// GetDFASyntaxDifference calculates score by measuring amount of edits
+// necessary to transform one dfa into the other
+// m2 is automata that is expected to be received
+// function returns result in scale from 0 to 1 and the edits that transform
+// m1 into an automaton equivalent to m2. Returned stats tell if ctx was done,
+// syntax diff timeout passed or node budget ran out before search finished.
+// Timeout is not applied in deterministic mode
+func GetDFASyntaxDifference(
+	ctx context.Context,
+	m1, m2 *dfa.DFA,
+) (float64, []Edit, Stats) {
+	ctx, cancel := withTimeout(ctx, config.DFADiff.Timeout)
+	defer cancel()
 
-	// wait until result or timeout
-	select {
-	case <-solver.timeouted:
-	case <-haveResult:
+	stats := Stats{Completed: true, CompletedDepth: -1}
+	m2Min, err := m2.Compile().Complete().Minimize(ctx)
+	if err != nil {
+		fmt.Println("Syntax diff: stopped,", err.Error())
+		stats.stopped(ctx)
+		return 0.0, nil, stats
 	}
 
-	solver.mu.Lock()
-	defer solver.mu.Unlock()
-	result := 1 - float64(*solver.solution)/float64(
-		len(m2Min.States())*len(m2Min.Alphabet()),
+	solver := newDFASyntaxSolver(
+		ctx, config.DFADiff.MaxDepth, config.DFADiff.MaxNodes, m2Min,
 	)
-	if result < 0.0 || *solver.solution == noResultScore {
-		result = 0.0
+	attempt := m1.Compile()
+	solver.seed(attempt)
+	edits, ok, err := solver.search(attempt)
+	stats.CompletedDepth = solver.completedDepth
+	stats.NodesExamined = int(solver.examined)
+	if err == errOutOfBudget {
+		fmt.Println("Syntax diff: stopped,", err.Error())
+		stats.Completed = false
+		stats.OutOfBudget = true
+		return 0.0, nil, stats
+	}
+	if err != nil {
+		fmt.Println("Syntax diff: stopped,", err.Error())
+		stats.stopped(ctx)
+		return 0.0, nil, stats
+	}
+	if !ok {
+		fmt.Println("Syntax diff: no solution found")
+		return 0.0, nil, stats
 	}
 
-	return result
+	result := 1 - float64(len(edits))/float64(
+		m2Min.NumStates()*m2Min.NumLetters(),
+	)
+	if result < 0.0 {
+		return 0.0, nil, stats
+	}
+
+	return result, edits, stats
 }
@@ -0,0 +1,310 @@
+package grader
+
+import (
+	"context"
+	"dfa-grader/config"
+	"dfa-grader/dfa"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// readDFA builds DFA from transitions written as "from letter to", the first
+// state is the start state and final states are marked with '*'
+func readDFA(t *testing.T, transitions ...string) *dfa.DFA {
+	t.Helper()
+	m := dfa.New()
+	state := func(name string) dfa.State {
+		final := strings.HasPrefix(name, "*")
+		s := dfa.State(strings.TrimPrefix(name, "*"))
+		if final {
+			m.SetFinalStates(append(m.FinalStates(), s)...)
+		}
+		return s
+	}
+	for i, tr := range transitions {
+		parts := strings.Fields(tr)
+		if len(parts) != 3 {
+			t.Fatalf("invalid transition '%s'", tr)
+		}
+		from, to := state(parts[0]), state(parts[2])
+		if i == 0 {
+			m.SetStartState(from)
+		}
+		if err := m.SetTransition(from, dfa.Letter(parts[1]), to); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+// applyEdits applies edits found by syntax diff to a copy of automaton
+func applyEdits(m *dfa.DFA, edits []Edit) *dfa.DFA {
+	m = m.Copy()
+	for _, e := range edits {
+		switch e.Kind {
+		case EditAddState:
+			for _, l := range m.Alphabet() {
+				m.SetTransition(e.State, l, e.State) // nolint: errcheck
+			}
+		case EditChangeStart:
+			m.SetStartState(e.State)
+		case EditToggleFinal:
+			var finals []dfa.State
+			for _, s := range m.FinalStates() {
+				if s != e.State {
+					finals = append(finals, s)
+				}
+			}
+			if e.Final {
+				finals = append(finals, e.State)
+			}
+			m.SetFinalStates(finals...)
+		case EditRedirect:
+			m.SetTransition(e.State, e.Letter, e.To) // nolint: errcheck
+		}
+	}
+	return m
+}
+
+// edits returns all automata obtained from c by a single edit
+func edits(c *dfa.Compiled) []*dfa.Compiled {
+	result := []*dfa.Compiled{c.WithState(c.NewStateName())}
+	for s := 0; s < c.NumStates(); s++ {
+		if s != c.Start() {
+			result = append(result, c.WithStart(s))
+		}
+		result = append(result, c.WithFinal(s, !c.IsFinal(s)))
+		for a := 0; a < c.NumLetters(); a++ {
+			for to := 0; to < c.NumStates(); to++ {
+				if to != c.Next(s, a) {
+					result = append(result, c.WithTransition(s, a, to))
+				}
+			}
+		}
+	}
+	return result
+}
+
+// bruteDistance finds the least number of edits that make attempt
+// equivalent to target by trying all edits, -1 if more than maxDepth are
+// needed
+func bruteDistance(attempt, target *dfa.Compiled, maxDepth int) int {
+	frontier := []*dfa.Compiled{attempt}
+	for depth := 0; depth <= maxDepth; depth++ {
+		var next []*dfa.Compiled
+		for _, c := range frontier {
+			if _, differ := c.DistinguishingWord(target); !differ {
+				return depth
+			}
+			next = append(next, edits(c)...)
+		}
+		frontier = next
+	}
+	return -1
+}
+
+func setupSyntaxDiff(t *testing.T, maxDepth int) {
+	t.Helper()
+	if err := config.Read(""); err != nil {
+		t.Fatal(err)
+	}
+	config.Deterministic = true
+	config.DFADiff.MaxDepth = maxDepth
+	config.DFADiff.MaxNodes = 0
+}
+
+func TestDFASyntaxDifference(t *testing.T) {
+	setupSyntaxDiff(t, 2)
+
+	// words over {a, b} ending with a
+	target := []string{"p a *q", "p b p", "*q a *q", "*q b p"}
+	tests := []struct {
+		name    string
+		attempt []string
+		edits   int
+	}{
+		{
+			name:    "identical",
+			attempt: target,
+			edits:   0,
+		},
+		{
+			name:    "renamed states",
+			attempt: []string{"x b x", "x a *y", "*y b x", "*y a *y"},
+			edits:   0,
+		},
+		{
+			name:    "non-accepting final state",
+			attempt: []string{"p a q", "p b p", "q a q", "q b p"},
+			edits:   1,
+		},
+		{
+			name:    "wrong transition",
+			attempt: []string{"p a *q", "p b p", "*q a p", "*q b p"},
+			edits:   1,
+		},
+		{
+			name:    "missing transition",
+			attempt: []string{"p a *q", "p b p", "*q a *q"},
+			edits:   1,
+		},
+		{
+			name:    "wrong start state",
+			attempt: []string{"*q a *q", "*q b p", "p a *q", "p b p"},
+			edits:   1,
+		},
+		{
+			name:    "two wrong transitions",
+			attempt: []string{"p a p", "p b *q", "*q a *q", "*q b p"},
+			edits:   2,
+		},
+		{
+			name:    "wrong transition and final state",
+			attempt: []string{"*p a q", "*p b *p", "q a q", "q b *p"},
+			edits:   2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m1, m2 := readDFA(t, tt.attempt...), readDFA(t, target...)
+			score, found, stats := GetDFASyntaxDifference(
+				context.Background(), m1, m2,
+			)
+			if !stats.Completed {
+				t.Fatalf("search did not complete: %+v", stats)
+			}
+			if len(found) != tt.edits {
+				t.Fatalf("found edits %v, want %d edits", found, tt.edits)
+			}
+			if want := 1 - float64(tt.edits)/4; score != want {
+				t.Errorf("score %f, want %f", score, want)
+			}
+			fixed := applyEdits(m1, found)
+			if eq, _ := dfa.Compare(context.Background(), fixed, m2); !eq {
+				t.Errorf("edits %v do not fix the attempt", found)
+			}
+		})
+	}
+}
+
+func TestDFASyntaxDifferenceAddState(t *testing.T) {
+	setupSyntaxDiff(t, 3)
+
+	// even number of a's needs two states, the attempt has only one
+	target := readDFA(t, "*e a o", "*e b *e", "o a *e", "o b o")
+	attempt := readDFA(t, "*e a *e", "*e b *e")
+	_, found, stats := GetDFASyntaxDifference(
+		context.Background(), attempt, target,
+	)
+	if !stats.Completed {
+		t.Fatalf("search did not complete: %+v", stats)
+	}
+	if len(found) != 3 || found[0].Kind != EditAddState {
+		t.Fatalf("found edits %v, want state added and 2 redirects", found)
+	}
+	fixed := applyEdits(attempt, found)
+	if eq, _ := dfa.Compare(context.Background(), fixed, target); !eq {
+		t.Errorf("edits %v do not fix the attempt", found)
+	}
+}
+
+func TestDFASyntaxDifferenceIncompleteAttempt(t *testing.T) {
+	setupSyntaxDiff(t, 1)
+
+	// only word a, the attempt has no transitions to the sink, so it has
+	// fewer states than the minimized target, yet one edit fixes it
+	target := readDFA(
+		t, "p a *q", "p b s", "*q a s", "*q b s", "s a s", "s b s",
+	)
+	attempt := readDFA(t, "p a q")
+	attempt.SetLetter("b")
+	_, found, stats := GetDFASyntaxDifference(
+		context.Background(), attempt, target,
+	)
+	if !stats.Completed {
+		t.Fatalf("search did not complete: %+v", stats)
+	}
+	if len(found) != 1 || found[0].Kind != EditToggleFinal {
+		t.Fatalf("found edits %v, want q made accepting", found)
+	}
+}
+
+// TestDFASyntaxDifferenceOptimal compares parallel search with trying all
+// edits on small random automata
+func TestDFASyntaxDifferenceOptimal(t *testing.T) {
+	const maxDepth = 2
+	setupSyntaxDiff(t, maxDepth)
+
+	r := rand.New(rand.NewSource(1))
+	alphabet := []dfa.Letter{"a", "b"}
+	random := func(n int) *dfa.DFA {
+		m := dfa.New()
+		var finals []dfa.State
+		for s := 0; s < n; s++ {
+			from := dfa.State(fmt.Sprint("q", s))
+			m.SetState(from)
+			for _, l := range alphabet {
+				to := dfa.State(fmt.Sprint("q", r.Intn(n)))
+				m.SetTransition(from, l, to) // nolint: errcheck
+			}
+			if r.Intn(2) == 0 {
+				finals = append(finals, from)
+			}
+		}
+		m.SetStartState("q0")
+		m.SetFinalStates(finals...)
+		return m
+	}
+
+	counts := make(map[int]int)
+	for i := 0; i < 90; i++ {
+		target := random(1 + r.Intn(3))
+		// most attempts are the target with a few random edits, so that
+		// they can be fixed within maximum depth
+		attempt := random(1 + r.Intn(3))
+		if i%3 != 0 {
+			c := target.Compile()
+			for e := r.Intn(maxDepth + 1); e > 0; e-- {
+				next := edits(c)
+				c = next[r.Intn(len(next))]
+			}
+			attempt = c.DFA()
+		}
+		targetMin, err := target.Compile().Complete().Minimize(
+			context.Background(),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := bruteDistance(attempt.Compile(), targetMin, maxDepth)
+
+		_, found, stats := GetDFASyntaxDifference(
+			context.Background(), attempt, target,
+		)
+		if !stats.Completed {
+			t.Fatalf("search did not complete: %+v", stats)
+		}
+		got := len(found)
+		if found == nil {
+			got = -1
+			if want == 0 {
+				got = 0
+			}
+		}
+		if got != want {
+			t.Fatalf(
+				"pair %d: found edits %v, want %d edits\n"+
+					"attempt:\n%s\ntarget:\n%s",
+				i, found, want, attempt.GraphViz(), target.GraphViz(),
+			)
+		}
+		counts[want]++
+	}
+	for depth := -1; depth <= maxDepth; depth++ {
+		if counts[depth] == 0 {
+			t.Errorf("no pair needs %d edits", depth)
+		}
+	}
+}
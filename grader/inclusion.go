@@ -0,0 +1,72 @@
+package grader
+
+import (
+	"dfa-grader/config"
+	"dfa-grader/dfa"
+	"fmt"
+)
+
+// Relation describes how language of attempted automaton relates to the
+// language of the target
+type Relation int
+
+// Possible relations of attempted and target languages
+const (
+	RelationEqual Relation = iota
+	// RelationSubset means that attempt rejects some words of the target
+	// and accepts nothing else
+	RelationSubset
+	// RelationSuperset means that attempt accepts all words of the target
+	// and some more
+	RelationSuperset
+	RelationIncomparable
+)
+
+func (r Relation) String() string {
+	switch r {
+	case RelationEqual:
+		return "equal"
+	case RelationSubset:
+		return "accepts too little"
+	case RelationSuperset:
+		return "accepts too much"
+	case RelationIncomparable:
+		return "incomparable"
+	}
+	return "unknown relation"
+}
+
+// GetLanguageRelation checks inclusion of languages in both directions
+// m2 is automata that is expected to be received
+func GetLanguageRelation(m1, m2 *dfa.DFA) Relation {
+	sub, _ := dfa.Subset(m1, m2)
+	super, _ := dfa.Subset(m2, m1)
+	switch {
+	case sub && super:
+		return RelationEqual
+	case sub:
+		return RelationSubset
+	case super:
+		return RelationSuperset
+	}
+	return RelationIncomparable
+}
+
+// GetInclusionScore calculates score from relation of languages, attempt
+// that accepts too little or too much gets configured partial credit
+// m2 is automata that is expected to be received
+// function returns result in scale from 0 to 1
+func GetInclusionScore(m1, m2 *dfa.DFA) (float64, Relation) {
+	relation := GetLanguageRelation(m1, m2)
+	fmt.Printf("Inclusion: attempt %s\n", relation)
+
+	switch relation {
+	case RelationEqual:
+		return 1.0, relation
+	case RelationSubset:
+		return config.Inclusion.TooLittle, relation
+	case RelationSuperset:
+		return config.Inclusion.TooMuch, relation
+	}
+	return 0.0, relation
+}
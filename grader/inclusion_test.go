@@ -0,0 +1,48 @@
+package grader
+
+import (
+	"dfa-grader/config"
+	"testing"
+)
+
+func TestGetInclusionScore(t *testing.T) {
+	if err := config.Read(""); err != nil {
+		t.Fatal(err)
+	}
+	config.Inclusion.TooLittle = 0.25
+	config.Inclusion.TooMuch = 0.5
+
+	// words over {a, b} ending with a
+	target := []string{"p a *q", "p b p", "*q a *q", "*q b p"}
+	tests := []struct {
+		name     string
+		attempt  []string
+		relation Relation
+		score    float64
+	}{
+		{"equivalent", []string{"x b x", "x a *y", "*y b x", "*y a *y"}, RelationEqual, 1},
+		{"only a", []string{"p a *q", "p b r"}, RelationSubset, 0.25},
+		{"empty", []string{"p a p", "p b p"}, RelationSubset, 0.25},
+		{"all words", []string{"*p a *p", "*p b *p"}, RelationSuperset, 0.5},
+		{"nonempty words", []string{"p a *q", "p b *q", "*q a *q", "*q b *q"}, RelationSuperset, 0.5},
+		{"words ending with b", []string{"*p a q", "*p b *p", "q a q", "q b *p"}, RelationIncomparable, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m1, m2 := readDFA(t, tt.attempt...), readDFA(t, target...)
+			score, relation := GetInclusionScore(m1, m2)
+			if relation != tt.relation || score != tt.score {
+				t.Errorf(
+					"got %s with score %f, want %s with %f",
+					relation, score, tt.relation, tt.score,
+				)
+			}
+			// relation in the other direction swaps subset and superset
+			back := GetLanguageRelation(m2, m1)
+			if (back == RelationSubset) != (tt.relation == RelationSuperset) ||
+				(back == RelationEqual) != (tt.relation == RelationEqual) {
+				t.Errorf("reversed relation %s for %s", back, tt.relation)
+			}
+		})
+	}
+}
@@ -1,162 +1,72 @@
 package grader
 
 import (
+	"context"
 	"dfa-grader/config"
 	"dfa-grader/dfa"
 	"fmt"
-	"time"
+	"math/big"
 )
 
-func getWordsUpToN(
-	m *dfa.DFA,
-	n int,
-	returns chan<- map[string]bool,
-	kill <-chan struct{},
-) {
-	prevStates := make(map[string]dfa.State)
-
-	prevStates[""] = m.StartState()
-	returns <- map[string]bool{
-		"": m.IsFinal(m.StartState()),
-	}
-
-	for i := 1; i <= n; i++ {
-		nextStates := make(map[string]dfa.State)
-		words := make(map[string]bool)
-
-		for word, state := range prevStates {
-			for _, l := range m.Alphabet() {
-				nextState, err := m.TransitionTarget(state, l)
-				if err != nil {
-					fmt.Printf("ERROR: %s\n", err.Error())
-					continue
-				}
-				nextWord := word + string(l)
-				// automata is deterministic, there is only one path to nextWord
-				nextStates[nextWord] = nextState
-				words[nextWord] = m.IsFinal(nextState)
-			}
-		}
-
-		select {
-		case <-kill:
-			return
-		case returns <- words:
-		}
-
-		prevStates = make(map[string]dfa.State)
-		for k, v := range nextStates {
-			prevStates[k] = v
-		}
-	}
-}
-
-// nolint: gocyclo
-func calculateLangDiff(
-	n int,
-	words1, words2 <-chan map[string]bool,
-	kill chan struct{},
-	scores chan<- float64,
-) {
-	for i := 0; i <= n; i++ {
-		different := make(map[string]bool)
-		// l2 is size of language(m2)
-		var l2 int
-		var w1, w2 map[string]bool
-
-		select {
-		case w1 = <-words1:
-		case <-kill:
-			return
-		}
-		select {
-		case w2 = <-words2:
-		case <-kill:
-			return
-		}
-
-		// for loops implement xor of languages
-		for w, res := range w1 {
-			if res != w2[w] {
-				different[w] = true
-			}
-		}
-		for w, res := range w2 {
-			if res != w1[w] {
-				different[w] = true
-			}
-			if res {
-				l2++
-			}
-		}
-
-		if l2 == 0 {
-			l2 = 1
-		}
-
-		score := float64(len(different)) / float64(l2)
-		fmt.Printf("Lang diff: length %d, score %f\n", i, score)
-		scores <- score
+// langDiffScore calculates score for words of counter's current length,
+// which is the number of words accepted by exactly one automaton divided by
+// the number of words accepted by the second one
+func langDiffScore(counter *dfa.WordCounter) float64 {
+	l2 := counter.AcceptedBySecond()
+	if l2.Sign() == 0 {
+		l2.SetInt64(1)
 	}
+	score, _ := new(big.Rat).SetFrac(counter.Differing(), l2).Float64()
+	return score
 }
 
 // GetLanguageDifference calculates score given metric to check how many words
 // differ for the languages
-// Automata MUST be determinized
 // m2 is automata that is expected to be received
-func GetLanguageDifference(m1, m2 *dfa.DFA) float64 {
-	n := config.LangDiff.MaxDepth - len(m2.Alphabet())
-	if len(m2.Alphabet()) == 5 {
-		// worst case
-		n--
-	}
+// Calculation stops when ctx is done or language diff timeout passes, then
+// only lengths checked so far are used, which is reported in returned stats.
+// Timeout is not applied in deterministic mode
+func GetLanguageDifference(
+	ctx context.Context,
+	m1, m2 *dfa.DFA,
+) (float64, Stats) {
+	n := config.LangDiff.MaxDepth
 	if n < config.LangDiff.MinDepth {
 		n = config.LangDiff.MinDepth
 	}
 
-	kill := make(chan struct{})
-	go func() {
-		time.Sleep(config.LangDiff.Timeout)
-		fmt.Println("Lang diff: Timeouted")
-		close(kill)
-	}()
-
-	words1 := make(chan map[string]bool)
-	words2 := make(chan map[string]bool)
-
-	go getWordsUpToN(m1, n, words1, kill)
-	go getWordsUpToN(m2, n, words2, kill)
-
-	nDiffs := make(chan float64)
-
-	go calculateLangDiff(n, words1, words2, kill, nDiffs)
+	ctx, cancel := withTimeout(ctx, config.LangDiff.Timeout)
+	defer cancel()
 
 	var summaryDiff float64
-
 	var received int
+	stats := Stats{Completed: true, WordsExamined: new(big.Int)}
+	counter := dfa.NewWordCounter(m1, m2)
 	// use n+1 because we test words of length from 0 to n
 	for received < n+1 {
-		var end bool
-		select {
-		case v := <-nDiffs:
-			received++
-			summaryDiff += v
-		case <-kill:
-			end = true
-			fmt.Println("Lang diff: killed by timeout")
-		}
-		if end {
+		if ctx.Err() != nil {
+			fmt.Println("Lang diff: stopped,", ctx.Err().Error())
+			stats.stopped(ctx)
 			break
 		}
+		if received > 0 {
+			counter.Next()
+		}
+		score := langDiffScore(counter)
+		fmt.Printf("Lang diff: length %d, score %f\n", received, score)
+		summaryDiff += score
+		stats.WordsExamined.Add(stats.WordsExamined, counter.Words())
+		received++
 	}
+	stats.CompletedDepth = received - 1
 
 	if received == 0 {
-		return 0.0
+		return 0.0, stats
 	}
 	if summaryDiff == 0 {
-		return 1.0
+		return 1.0, stats
 	}
 
 	unscaled := summaryDiff / float64(received)
-	return (6 / (unscaled + 6)) - 0.1
+	return (6 / (unscaled + 6)) - 0.1, stats
 }
@@ -0,0 +1,85 @@
+package grader
+
+import (
+	"context"
+	"dfa-grader/config"
+	"dfa-grader/dfa"
+	"math"
+	"testing"
+)
+
+// accepts follows transitions of DFA on letters, missing transition rejects
+func accepts(m *dfa.DFA, word []dfa.Letter) bool {
+	s := m.StartState()
+	for _, l := range word {
+		to, err := m.TransitionTarget(s, l)
+		if err != nil {
+			return false
+		}
+		s = to
+	}
+	return m.IsFinal(s)
+}
+
+// bruteLanguageDifference computes language diff score by enumerating all
+// words of length at most n
+func bruteLanguageDifference(m1, m2 *dfa.DFA, n int) float64 {
+	alphabet := m1.Alphabet()
+	var summary float64
+	words := [][]dfa.Letter{{}}
+	for length := 0; length <= n; length++ {
+		var diff, second float64
+		var next [][]dfa.Letter
+		for _, w := range words {
+			if accepts(m1, w) != accepts(m2, w) {
+				diff++
+			}
+			if accepts(m2, w) {
+				second++
+			}
+			for _, l := range alphabet {
+				next = append(next, append(append([]dfa.Letter{}, w...), l))
+			}
+		}
+		summary += diff / math.Max(second, 1)
+		words = next
+	}
+	if summary == 0 {
+		return 1
+	}
+	return 6/(summary/float64(n+1)+6) - 0.1
+}
+
+func TestGetLanguageDifference(t *testing.T) {
+	if err := config.Read(""); err != nil {
+		t.Fatal(err)
+	}
+	config.Deterministic = true
+	config.LangDiff.MinDepth = 2
+	config.LangDiff.MaxDepth = 8
+
+	// words over {a, b} ending with a
+	target := []string{"p a *q", "p b p", "*q a *q", "*q b p"}
+	tests := []struct {
+		name    string
+		attempt []string
+	}{
+		{"equivalent", []string{"x b x", "x a *y", "*y b x", "*y a *y"}},
+		{"words ending with b", []string{"*p a q", "*p b *p", "q a q", "q b *p"}},
+		{"all words", []string{"*p a *p", "*p b *p"}},
+		{"only a", []string{"p a *q", "p b r"}},
+		{"a first", []string{"p a *q", "*q a *q", "*q b *q"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m1, m2 := readDFA(t, tt.attempt...), readDFA(t, target...)
+			got, stats := GetLanguageDifference(context.Background(), m1, m2)
+			if !stats.Completed || stats.CompletedDepth != 8 {
+				t.Fatalf("calculation did not complete: %+v", stats)
+			}
+			if want := bruteLanguageDifference(m1, m2, 8); math.Abs(got-want) > 1e-9 {
+				t.Errorf("score %f, want %f", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package grader
+
+import (
+	"context"
+	"dfa-grader/config"
+	"dfa-grader/dfa"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Names of built-in scoring methods
+const (
+	LangDiffName      = "langDiff"
+	DFASyntaxDiffName = "dfaSyntaxDiff"
+	DensityDiffName   = "densityDiff"
+	InclusionName     = "inclusion"
+)
+
+// Result is outcome of a single scoring method
+type Result struct {
+	Score float64 // score in scale from 0 to 1
+	Edits []Edit  // edits that fix the attempt, if method finds them
+	Stats Stats
+}
+
+// Stats describes how far calculation of a method got, so that grades based
+// on partial calculation can be reviewed
+type Stats struct {
+	// Completed is false if calculation was cut off by timeout or
+	// cancellation, in that case score is based on partial calculation
+	Completed bool
+	// TimedOut is true if calculation was cut off by timeout
+	TimedOut bool
+	// OutOfBudget is true if syntax diff checked all automata it was allowed
+	// to before finding a solution
+	OutOfBudget bool
+	// CompletedDepth is the longest word length checked by language diff or
+	// the largest number of edits fully searched by syntax diff, -1 if none
+	CompletedDepth int
+	// WordsExamined is number of words compared by language diff
+	WordsExamined *big.Int
+	// NodesExamined is number of automata checked by syntax diff
+	NodesExamined int
+	Elapsed       time.Duration
+}
+
+// stopped fills stats for calculation that was cut off
+func (s *Stats) stopped(ctx context.Context) {
+	s.Completed = false
+	s.TimedOut = ctx.Err() == context.DeadlineExceeded
+}
+
+// withTimeout limits calculation of a method by timeout. In deterministic
+// mode timeout is not applied, so that the result does not depend on load
+func withTimeout(
+	ctx context.Context,
+	timeout time.Duration,
+) (context.Context, context.CancelFunc) {
+	if config.Deterministic {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Method is a way of scoring attempted automaton against the target
+type Method interface {
+	// Name identifies method in configuration and response
+	Name() string
+	// Score compares attempt with the target, both automata are determinized.
+	// Method should stop when ctx is done and report it in Result
+	Score(ctx context.Context, attempt, target *dfa.DFA) (Result, error)
+}
+
+var (
+	registryMu = &sync.Mutex{}
+	registry   = make(map[string]Method)
+)
+
+// Register makes method available for grading under its name, registering
+// two methods with the same name is an error
+func Register(m Method) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[m.Name()]; ok {
+		return fmt.Errorf("method '%s' is already registered", m.Name())
+	}
+	registry[m.Name()] = m
+	return nil
+}
+
+// Lookup returns registered method with given name
+func Lookup(name string) (Method, bool) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	m, ok := registry[name]
+	return m, ok
+}
+
+// Methods returns sorted names of all registered methods
+func Methods() []string {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// funcMethod adapts scoring function to Method interface
+type funcMethod struct {
+	name  string
+	score func(ctx context.Context, attempt, target *dfa.DFA) Result
+}
+
+func (m *funcMethod) Name() string {
+	return m.name
+}
+
+func (m *funcMethod) Score(
+	ctx context.Context,
+	attempt, target *dfa.DFA,
+) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+	start := time.Now()
+	res := m.score(ctx, attempt, target)
+	res.Stats.Elapsed = time.Since(start)
+	return res, nil
+}
+
+func init() {
+	builtin := []Method{
+		&funcMethod{
+			name: LangDiffName,
+			score: func(ctx context.Context, attempt, target *dfa.DFA) Result {
+				score, stats := GetLanguageDifference(ctx, attempt, target)
+				return Result{Score: score, Stats: stats}
+			},
+		},
+		&funcMethod{
+			name: DFASyntaxDiffName,
+			score: func(ctx context.Context, attempt, target *dfa.DFA) Result {
+				score, edits, stats := GetDFASyntaxDifference(
+					ctx, attempt, target,
+				)
+				return Result{Score: score, Edits: edits, Stats: stats}
+			},
+		},
+		&funcMethod{
+			name: DensityDiffName,
+			score: func(ctx context.Context, attempt, target *dfa.DFA) Result {
+				score, stats := GetDensityDifference(ctx, attempt, target)
+				return Result{Score: score, Stats: stats}
+			},
+		},
+		&funcMethod{
+			name: InclusionName,
+			score: func(ctx context.Context, attempt, target *dfa.DFA) Result {
+				score, _ := GetInclusionScore(attempt, target)
+				return Result{
+					Score: score,
+					Stats: Stats{Completed: true, CompletedDepth: -1},
+				}
+			},
+		},
+	}
+	for _, m := range builtin {
+		Register(m) // nolint: errcheck,gas
+	}
+}
@@ -0,0 +1,92 @@
+package grader
+
+import (
+	"context"
+	"dfa-grader/config"
+	"dfa-grader/dfa"
+	"reflect"
+	"testing"
+)
+
+// constantMethod gives the same score to every attempt
+type constantMethod struct {
+	name  string
+	score float64
+}
+
+func (m *constantMethod) Name() string {
+	return m.name
+}
+
+func (m *constantMethod) Score(
+	ctx context.Context,
+	attempt, target *dfa.DFA,
+) (Result, error) {
+	return Result{Score: m.score}, nil
+}
+
+func TestRegister(t *testing.T) {
+	builtin := []string{
+		DensityDiffName, DFASyntaxDiffName, InclusionName, LangDiffName,
+	}
+	if names := Methods(); !reflect.DeepEqual(names, builtin) {
+		t.Fatalf("registered methods %v, want %v", names, builtin)
+	}
+
+	if _, ok := Lookup("constant"); ok {
+		t.Fatal("unknown method is found")
+	}
+	if err := Register(&constantMethod{name: "constant", score: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		registryMu.Lock()
+		delete(registry, "constant")
+		registryMu.Unlock()
+	}()
+	m, ok := Lookup("constant")
+	if !ok || m.Name() != "constant" {
+		t.Fatal("registered method is not found")
+	}
+	if err := Register(&constantMethod{name: "constant"}); err == nil {
+		t.Error("method registered twice")
+	}
+	if err := Register(&constantMethod{name: LangDiffName}); err == nil {
+		t.Error("built-in method replaced")
+	}
+	if res, _ := m.Score(context.Background(), nil, nil); res.Score != 0.5 {
+		t.Error("method registered twice replaced the first one")
+	}
+}
+
+func TestBuiltinMethods(t *testing.T) {
+	if err := config.Read(""); err != nil {
+		t.Fatal(err)
+	}
+	config.Deterministic = true
+
+	attempt := readDFA(t, "p a *q", "p b p", "*q a *q", "*q b p")
+	target := readDFA(t, "x b x", "x a *y", "*y b x", "*y a *y")
+	for _, name := range []string{
+		LangDiffName, DFASyntaxDiffName, DensityDiffName, InclusionName,
+	} {
+		m, ok := Lookup(name)
+		if !ok {
+			t.Fatalf("method %s is not registered", name)
+		}
+		res, err := m.Score(context.Background(), attempt, target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Score != 1 || !res.Stats.Completed {
+			t.Errorf("%s: got result %+v for equivalent automata", name, res)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	m, _ := Lookup(LangDiffName)
+	if _, err := m.Score(ctx, attempt, target); err == nil {
+		t.Error("method ran after cancellation")
+	}
+}
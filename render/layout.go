@@ -0,0 +1,177 @@
+package render
+
+import (
+	"dfa-grader/dfa"
+	"math"
+	"sort"
+)
+
+// distances between states in layouts
+const (
+	layerSpacing = 140.0
+	stateSpacing = 90.0
+	// sweeps of barycenter heuristic that orders states of layers
+	orderingSweeps = 4
+)
+
+type point struct {
+	x, y float64
+}
+
+func (p point) add(o point) point {
+	return point{x: p.x + o.x, y: p.y + o.y}
+}
+
+func (p point) sub(o point) point {
+	return point{x: p.x - o.x, y: p.y - o.y}
+}
+
+func (p point) scale(f float64) point {
+	return point{x: p.x * f, y: p.y * f}
+}
+
+func (p point) length() float64 {
+	return math.Hypot(p.x, p.y)
+}
+
+// unit returns vector of length 1 in the same direction, or zero vector
+func (p point) unit() point {
+	l := p.length()
+	if l == 0 {
+		return point{}
+	}
+	return p.scale(1 / l)
+}
+
+// normal returns p rotated by 90 degrees
+func (p point) normal() point {
+	return point{x: -p.y, y: p.x}
+}
+
+// layered places states in columns by their distance from the start state,
+// unreachable states are in the last column. States of each column are
+// ordered by average position of their neighbours in adjacent columns, so
+// that fewer edges cross
+func layered(c *dfa.Compiled, visible []bool) (map[int]point, map[int]int) {
+	n, k := c.NumStates(), c.NumLetters()
+
+	layer := make(map[int]int, n)
+	layer[c.Start()] = 0
+	queue := []int{c.Start()}
+	for i := 0; i < len(queue); i++ {
+		s := queue[i]
+		for a := 0; a < k; a++ {
+			to := c.Next(s, a)
+			if to < 0 || !visible[to] {
+				continue
+			}
+			if _, ok := layer[to]; !ok {
+				layer[to] = layer[s] + 1
+				queue = append(queue, to)
+			}
+		}
+	}
+	last := layer[queue[len(queue)-1]]
+	for s := 0; s < n; s++ {
+		if _, ok := layer[s]; !ok && visible[s] {
+			layer[s] = last + 1
+		}
+	}
+
+	var layers [][]int
+	for s := 0; s < n; s++ {
+		if !visible[s] {
+			continue
+		}
+		for len(layers) <= layer[s] {
+			layers = append(layers, nil)
+		}
+		layers[layer[s]] = append(layers[layer[s]], s)
+	}
+
+	neighbours := make([][]int, n)
+	for s := 0; s < n; s++ {
+		for a := 0; a < k && visible[s]; a++ {
+			to := c.Next(s, a)
+			if to >= 0 && to != s && visible[to] {
+				neighbours[s] = append(neighbours[s], to)
+				neighbours[to] = append(neighbours[to], s)
+			}
+		}
+	}
+	index := make([]int, n)
+	reorder := func(l, adjacent int) {
+		barycenter := make(map[int]float64, len(layers[l]))
+		for _, s := range layers[l] {
+			sum, count := 0.0, 0
+			for _, t := range neighbours[s] {
+				if layer[t] == adjacent {
+					sum += float64(index[t])
+					count++
+				}
+			}
+			barycenter[s] = float64(index[s])
+			if count > 0 {
+				barycenter[s] = sum / float64(count)
+			}
+		}
+		sort.SliceStable(layers[l], func(i, j int) bool {
+			return barycenter[layers[l][i]] < barycenter[layers[l][j]]
+		})
+		for i, s := range layers[l] {
+			index[s] = i
+		}
+	}
+	for l := range layers {
+		for i, s := range layers[l] {
+			index[s] = i
+		}
+	}
+	for sweep := 0; sweep < orderingSweeps; sweep++ {
+		if sweep%2 == 0 {
+			for l := 1; l < len(layers); l++ {
+				reorder(l, l-1)
+			}
+		} else {
+			for l := len(layers) - 2; l >= 0; l-- {
+				reorder(l, l+1)
+			}
+		}
+	}
+
+	positions := make(map[int]point, n)
+	for l, states := range layers {
+		for i, s := range states {
+			positions[s] = point{
+				x: float64(l) * layerSpacing,
+				y: (float64(i) - float64(len(states)-1)/2) * stateSpacing,
+			}
+		}
+	}
+	return positions, layer
+}
+
+// circular places states on a circle in the order of Compile, starting on
+// the left side
+func circular(c *dfa.Compiled, visible []bool) map[int]point {
+	var states []int
+	for s := 0; s < c.NumStates(); s++ {
+		if visible[s] {
+			states = append(states, s)
+		}
+	}
+	positions := make(map[int]point, len(states))
+	if len(states) == 1 {
+		positions[states[0]] = point{}
+		return positions
+	}
+	radius := math.Max(stateSpacing, stateSpacing*float64(len(states))/(2*math.Pi))
+	for i, s := range states {
+		angle := math.Pi + 2*math.Pi*float64(i)/float64(len(states))
+		positions[s] = point{
+			x: radius * math.Cos(angle),
+			y: radius * math.Sin(angle),
+		}
+	}
+	return positions
+}
@@ -0,0 +1,337 @@
+// Package render draws automata as SVG images without external tools
+package render
+
+import (
+	"bufio"
+	"dfa-grader/dfa"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Layout describes how states are placed
+type Layout int
+
+// Layouts of automata
+const (
+	// Layered places states in columns by distance from the start state
+	Layered Layout = iota
+	// Circular places states on a circle
+	Circular
+)
+
+// Transition identifies transition of DFA
+type Transition struct {
+	From   dfa.State
+	Letter dfa.Letter
+}
+
+// Options changes how automaton is drawn. Colors are any CSS colors
+type Options struct {
+	Layout Layout
+	// HideSink omits rejecting states other than the start state whose
+	// transitions all lead back to themselves, see dfa.GraphVizOptions
+	HideSink bool
+
+	StateColors      map[dfa.State]string
+	TransitionColors map[Transition]string
+}
+
+// HighlightWord colors states visited and transitions taken while reading
+// word from the start state
+func (o *Options) HighlightWord(m *dfa.DFA, word dfa.Word, color string) {
+	if o.StateColors == nil {
+		o.StateColors = make(map[dfa.State]string)
+	}
+	if o.TransitionColors == nil {
+		o.TransitionColors = make(map[Transition]string)
+	}
+	c := m.Compile()
+	walk := c.Walk(word)
+	for i, s := range walk {
+		o.StateColors[c.State(s)] = color
+		if i > 0 {
+			o.TransitionColors[Transition{
+				From: c.State(walk[i-1]), Letter: word[i-1],
+			}] = color
+		}
+	}
+}
+
+// sizes of drawn elements
+const (
+	stateRadius = 22.0
+	finalRadius = 18.0
+	startLength = 30.0
+	edgeSpacing = 30.0
+	loopHeight  = 40.0
+	fontSize    = 12.0
+	margin      = 20.0
+	// charWidth estimates width of a character, so that labels fit in image
+	charWidth = 7.0
+
+	defaultColor   = "black"
+	highlightWidth = 3.0
+)
+
+// edge is a drawn arrow for transitions with the same states and color
+type edge struct {
+	from, to int
+	color    string
+	letters  []string
+}
+
+// svgWriter collects elements of image and its bounding box
+type svgWriter struct {
+	body     strings.Builder
+	min, max point
+	markers  map[string]string
+	colors   []string
+}
+
+func (w *svgWriter) include(p point, dx, dy float64) {
+	w.min.x = math.Min(w.min.x, p.x-dx)
+	w.min.y = math.Min(w.min.y, p.y-dy)
+	w.max.x = math.Max(w.max.x, p.x+dx)
+	w.max.y = math.Max(w.max.y, p.y+dy)
+}
+
+// marker returns id of arrow head of given color
+func (w *svgWriter) marker(color string) string {
+	if id, ok := w.markers[color]; ok {
+		return id
+	}
+	id := fmt.Sprintf("arrow%d", len(w.colors))
+	w.markers[color] = id
+	w.colors = append(w.colors, color)
+	return id
+}
+
+func (w *svgWriter) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&w.body, format, args...)
+}
+
+func (w *svgWriter) text(p point, s string) {
+	w.include(p, float64(len([]rune(s)))*charWidth/2, fontSize)
+	w.printf(
+		`<text x="%.1f" y="%.1f" text-anchor="middle" `+
+			`dominant-baseline="central">%s</text>`+"\n",
+		p.x, p.y, escape(s),
+	)
+}
+
+func (w *svgWriter) arrow(d, color string) {
+	width := 1.0
+	if color != defaultColor {
+		width = highlightWidth
+	}
+	w.printf(
+		`<path d="%s" fill="none" stroke="%s" stroke-width="%.0f" `+
+			`marker-end="url(#%s)"/>`+"\n",
+		d, escape(color), width, w.marker(color),
+	)
+}
+
+// SVG draws automaton as SVG image. Start state has an incoming arrow, final
+// states are drawn with double circles and transitions between the same
+// states are merged into a single arrow labeled with comma separated
+// letters, unless they have different colors
+// nolint: gocyclo
+func SVG(out io.Writer, m *dfa.DFA, opts Options) error {
+	c := m.Compile()
+	n, k := c.NumStates(), c.NumLetters()
+
+	visible := make([]bool, n)
+	for s := range visible {
+		visible[s] = !opts.HideSink || !c.IsSink(s)
+	}
+	var positions map[int]point
+	var layer map[int]int
+	if opts.Layout == Circular {
+		positions = circular(c, visible)
+	} else {
+		positions, layer = layered(c, visible)
+	}
+
+	// group transitions into edges in order of states and letters
+	type edgeKey struct {
+		from, to int
+		color    string
+	}
+	var edges []*edge
+	byKey := make(map[edgeKey]*edge)
+	for s := 0; s < n; s++ {
+		for a := 0; a < k && visible[s]; a++ {
+			to := c.Next(s, a)
+			if to < 0 || !visible[to] {
+				continue
+			}
+			color := opts.TransitionColors[Transition{
+				From: c.State(s), Letter: c.Letter(a),
+			}]
+			if color == "" {
+				color = defaultColor
+			}
+			key := edgeKey{from: s, to: to, color: color}
+			e, ok := byKey[key]
+			if !ok {
+				e = &edge{from: s, to: to, color: color}
+				byKey[key] = e
+				edges = append(edges, e)
+			}
+			e.letters = append(e.letters, string(c.Letter(a)))
+		}
+	}
+
+	// edges between the same pair of states are bent apart
+	type pair struct{ a, b int }
+	parallel := make(map[pair][]*edge)
+	for _, e := range edges {
+		p := pair{a: e.from, b: e.to}
+		if p.a > p.b {
+			p.a, p.b = p.b, p.a
+		}
+		parallel[p] = append(parallel[p], e)
+	}
+
+	w := &svgWriter{markers: make(map[string]string)}
+	w.min, w.max = positions[c.Start()], positions[c.Start()]
+
+	loops := make(map[int]int)
+	for _, e := range edges {
+		label := strings.Join(e.letters, ",")
+		from := positions[e.from]
+		if e.from == e.to {
+			// loops of the same state are drawn above each other
+			size := loopHeight + float64(loops[e.from])*edgeSpacing/2
+			loops[e.from]++
+			spread := stateRadius * 0.9
+			p0 := from.add(point{x: -stateRadius / 2, y: -stateRadius * 0.87})
+			p3 := from.add(point{x: stateRadius / 2, y: -stateRadius * 0.87})
+			c1 := from.add(point{x: -spread, y: -stateRadius - size})
+			c2 := from.add(point{x: spread, y: -stateRadius - size})
+			w.include(c1, 0, 0)
+			w.include(c2, 0, 0)
+			w.arrow(fmt.Sprintf(
+				"M%.1f,%.1f C%.1f,%.1f %.1f,%.1f %.1f,%.1f",
+				p0.x, p0.y, c1.x, c1.y, c2.x, c2.y, p3.x, p3.y,
+			), e.color)
+			top := p0.add(p3).scale(0.125).add(c1.add(c2).scale(0.375))
+			w.text(top.add(point{y: -fontSize/2 - 2}), label)
+			continue
+		}
+
+		to := positions[e.to]
+		p := pair{a: e.from, b: e.to}
+		if p.a > p.b {
+			p.a, p.b = p.b, p.a
+		}
+		group := parallel[p]
+		var idx int
+		for i, other := range group {
+			if other == e {
+				idx = i
+			}
+		}
+		offset := (float64(idx) - float64(len(group)-1)/2) * edgeSpacing
+		// edges between states of the same or distant columns would cross
+		// states between them
+		if len(group) == 1 && layer != nil {
+			if d := layer[e.from] - layer[e.to]; d != 1 && d != -1 {
+				offset = edgeSpacing
+			}
+		}
+		normal := positions[p.b].sub(positions[p.a]).unit().normal()
+		mid := from.add(to).scale(0.5)
+		// peak of quadratic curve is half way to its control point
+		control := mid.add(normal.scale(2 * offset))
+		start := from.add(control.sub(from).unit().scale(stateRadius))
+		end := to.add(control.sub(to).unit().scale(stateRadius))
+		w.include(control, 0, 0)
+		w.arrow(fmt.Sprintf(
+			"M%.1f,%.1f Q%.1f,%.1f %.1f,%.1f",
+			start.x, start.y, control.x, control.y, end.x, end.y,
+		), e.color)
+		peak := start.add(end).scale(0.25).add(control.scale(0.5))
+		w.text(peak.add(point{y: -fontSize/2 - 2}), label)
+	}
+
+	start := positions[c.Start()]
+	w.arrow(fmt.Sprintf(
+		"M%.1f,%.1f L%.1f,%.1f",
+		start.x-stateRadius-startLength, start.y,
+		start.x-stateRadius, start.y,
+	), defaultColor)
+	w.include(start.add(point{x: -stateRadius - startLength}), 0, 0)
+
+	for s := 0; s < n; s++ {
+		if !visible[s] {
+			continue
+		}
+		p := positions[s]
+		color, width := opts.StateColors[c.State(s)], highlightWidth
+		if color == "" {
+			color, width = defaultColor, 1
+		}
+		w.include(p, stateRadius+width, stateRadius+width)
+		w.printf(
+			`<circle cx="%.1f" cy="%.1f" r="%.1f" fill="white" `+
+				`stroke="%s" stroke-width="%.0f"/>`+"\n",
+			p.x, p.y, stateRadius, escape(color), width,
+		)
+		if c.IsFinal(s) {
+			w.printf(
+				`<circle cx="%.1f" cy="%.1f" r="%.1f" fill="none" `+
+					`stroke="%s" stroke-width="%.0f"/>`+"\n",
+				p.x, p.y, finalRadius, escape(color), width,
+			)
+		}
+		w.text(p, string(c.State(s)))
+	}
+
+	return w.write(out)
+}
+
+// write writes collected elements as SVG document
+func (w *svgWriter) write(out io.Writer) error {
+	b := bufio.NewWriter(out)
+	x, y := w.min.x-margin, w.min.y-margin
+	width := w.max.x - w.min.x + 2*margin
+	height := w.max.y - w.min.y + 2*margin
+	fmt.Fprintf(b,
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" `+
+			`viewBox="%.1f %.1f %.1f %.1f" font-family="sans-serif" `+
+			`font-size="%.0f">`+"\n",
+		math.Ceil(width), math.Ceil(height), x, y, width, height, fontSize,
+	)
+	b.WriteString("<defs>\n")
+	colors := append([]string(nil), w.colors...)
+	sort.Strings(colors)
+	for _, color := range colors {
+		fmt.Fprintf(b,
+			`<marker id="%s" viewBox="0 0 10 10" refX="10" refY="5" `+
+				`markerWidth="8" markerHeight="8" markerUnits="userSpaceOnUse" `+
+				`orient="auto"><path d="M0,0 L10,5 L0,10 z" fill="%s"/></marker>`+
+				"\n",
+			w.markers[color], escape(color),
+		)
+	}
+	b.WriteString("</defs>\n")
+	fmt.Fprintf(b,
+		`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="white"/>`+"\n",
+		x, y, width, height,
+	)
+	b.WriteString(w.body.String())
+	b.WriteString("</svg>\n")
+	return b.Flush()
+}
+
+// escape escapes text for use in XML text and attributes
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s)) // nolint: errcheck,gas
+	return b.String()
+}
@@ -0,0 +1,176 @@
+package render
+
+import (
+	"dfa-grader/dfa"
+	"encoding/xml"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// svgImage has elements of drawn automaton that are checked by tests
+type svgImage struct {
+	ViewBox string `xml:"viewBox,attr"`
+	Markers []struct {
+		ID string `xml:"id,attr"`
+	} `xml:"defs>marker"`
+	Circles []struct {
+		CX     float64 `xml:"cx,attr"`
+		CY     float64 `xml:"cy,attr"`
+		R      float64 `xml:"r,attr"`
+		Stroke string  `xml:"stroke,attr"`
+	} `xml:"circle"`
+	Paths []struct {
+		Stroke    string `xml:"stroke,attr"`
+		MarkerEnd string `xml:"marker-end,attr"`
+	} `xml:"path"`
+	Texts []string `xml:"text"`
+}
+
+// draw renders DFA and parses the image
+func draw(t *testing.T, m *dfa.DFA, opts Options) *svgImage {
+	t.Helper()
+	var b strings.Builder
+	if err := SVG(&b, m, opts); err != nil {
+		t.Fatal(err)
+	}
+	var image svgImage
+	if err := xml.Unmarshal([]byte(b.String()), &image); err != nil {
+		t.Fatalf("%v\n%s", err, b.String())
+	}
+	return &image
+}
+
+func randomDFA(r *rand.Rand, n int, letters []dfa.Letter) *dfa.DFA {
+	m := dfa.New()
+	for _, l := range letters {
+		m.SetLetter(l)
+	}
+	var finals []dfa.State
+	for s := 0; s < n; s++ {
+		from := dfa.State(fmt.Sprint("s", s))
+		m.SetState(from)
+		for _, l := range letters {
+			if r.Intn(5) > 0 {
+				to := dfa.State(fmt.Sprint("s", r.Intn(n)))
+				m.SetTransition(from, l, to) // nolint: errcheck
+			}
+		}
+		if r.Intn(3) == 0 {
+			finals = append(finals, from)
+		}
+	}
+	m.SetStartState("s0")
+	m.SetFinalStates(finals...)
+	return m
+}
+
+func TestSVG(t *testing.T) {
+	// letters and names that need escaping in XML
+	letters := []dfa.Letter{"a", "<", "&"}
+	r := rand.New(rand.NewSource(1))
+	for _, layout := range []Layout{Layered, Circular} {
+		for i := 0; i < 50; i++ {
+			m := randomDFA(r, 1+r.Intn(8), letters)
+			m.SetTransition(`<q&"1>`, "a", "s0") // nolint: errcheck
+			image := draw(t, m, Options{Layout: layout})
+
+			var x, y, width, height float64
+			fmt.Sscan(image.ViewBox, &x, &y, &width, &height) // nolint: errcheck
+			// one circle per state, final states have one more, and all
+			// of them are inside the image at different places
+			var states, finals int
+			centers := make(map[[2]float64]bool)
+			for _, c := range image.Circles {
+				if c.R == stateRadius {
+					states++
+					if centers[[2]float64{c.CX, c.CY}] {
+						t.Fatalf("layout %d: states overlap at %f, %f", layout, c.CX, c.CY)
+					}
+					centers[[2]float64{c.CX, c.CY}] = true
+				} else {
+					finals++
+				}
+				if c.CX-c.R < x || c.CX+c.R > x+width ||
+					c.CY-c.R < y || c.CY+c.R > y+height {
+					t.Fatalf("layout %d: state at %f, %f is outside", layout, c.CX, c.CY)
+				}
+			}
+			var wantFinals int
+			for _, s := range m.States() {
+				if m.IsFinal(s) {
+					wantFinals++
+				}
+			}
+			if states != len(m.States()) || finals != wantFinals {
+				t.Fatalf(
+					"layout %d: got %d states, %d final, want %d, %d",
+					layout, states, finals, len(m.States()), wantFinals,
+				)
+			}
+
+			labels := make(map[string]bool)
+			for _, text := range image.Texts {
+				labels[text] = true
+			}
+			for _, s := range m.States() {
+				if !labels[string(s)] {
+					t.Fatalf("layout %d: state %s has no label", layout, s)
+				}
+			}
+			markers := make(map[string]bool)
+			for _, marker := range image.Markers {
+				markers[fmt.Sprintf("url(#%s)", marker.ID)] = true
+			}
+			for _, p := range image.Paths {
+				if !markers[p.MarkerEnd] {
+					t.Fatalf("layout %d: unknown marker %s", layout, p.MarkerEnd)
+				}
+			}
+		}
+	}
+}
+
+func TestSVGOptions(t *testing.T) {
+	// words with odd number of a's, "r" is a sink
+	m := dfa.New()
+	m.SetTransition("p", "a", "q") // nolint: errcheck
+	m.SetTransition("q", "a", "p") // nolint: errcheck
+	m.SetTransition("p", "b", "r") // nolint: errcheck
+	m.SetTransition("q", "b", "r") // nolint: errcheck
+	m.SetTransition("r", "a", "r") // nolint: errcheck
+	m.SetTransition("r", "b", "r") // nolint: errcheck
+	m.SetStartState("p")
+	m.SetFinalStates("q")
+
+	opts := Options{HideSink: true}
+	opts.HighlightWord(m, dfa.Word{"a", "a"}, "red")
+	image := draw(t, m, opts)
+
+	// both visited states and the inner circle of final state are
+	// highlighted
+	var red int
+	for _, c := range image.Circles {
+		if c.Stroke == "red" {
+			red++
+		}
+	}
+	if len(image.Circles) != 3 || red != 3 {
+		t.Errorf("got circles %+v", image.Circles)
+	}
+	for _, text := range image.Texts {
+		if text == "r" || strings.Contains(text, "b") {
+			t.Errorf("sink is drawn, got label %q", text)
+		}
+	}
+	// arrow of start state, and highlighted transitions merged into one
+	// arrow between p and q in each direction
+	var paths []string
+	for _, p := range image.Paths {
+		paths = append(paths, p.Stroke)
+	}
+	if strings.Join(paths, " ") != "red red black" || len(image.Markers) != 2 {
+		t.Errorf("got arrows of colors %v and %d markers", paths, len(image.Markers))
+	}
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"container/list"
+	"sync"
+)
+
+// gradeCache keeps responses for recently graded submissions, the least
+// recently used response is dropped when cache is full
+type gradeCache struct {
+	mu      *sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type cacheEntry struct {
+	key  string
+	resp response
+}
+
+func newGradeCache() *gradeCache {
+	return &gradeCache{
+		mu:      &sync.Mutex{},
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (c *gradeCache) get(key string) (response, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return response{}, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*cacheEntry).resp, true
+}
+
+// put stores response, cache keeps at most size responses
+func (c *gradeCache) put(key string, resp response, size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*cacheEntry).resp = resp
+		c.order.MoveToFront(e)
+	} else {
+		c.entries[key] = c.order.PushFront(&cacheEntry{key: key, resp: resp})
+	}
+	for c.order.Len() > size {
+		last := c.order.Back()
+		c.order.Remove(last)
+		delete(c.entries, last.Value.(*cacheEntry).key)
+	}
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"dfa-grader/config"
+	"dfa-grader/dfa"
+	"testing"
+)
+
+func TestGradeCache(t *testing.T) {
+	c := newGradeCache()
+	c.put("a", response{Message: "a"}, 2)
+	c.put("b", response{Message: "b"}, 2)
+	// reading makes entry recently used, so the oldest entry is "b"
+	if resp, ok := c.get("a"); !ok || resp.Message != "a" {
+		t.Fatalf("got %+v, %v for cached response", resp, ok)
+	}
+	c.put("c", response{Message: "c"}, 2)
+	if _, ok := c.get("b"); ok {
+		t.Error("the least recently used response is kept")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.get(key); !ok {
+			t.Errorf("response %s is dropped", key)
+		}
+	}
+
+	c.put("a", response{Message: "updated"}, 2)
+	if resp, _ := c.get("a"); resp.Message != "updated" {
+		t.Errorf("got %+v after update", resp)
+	}
+	c.put("d", response{Message: "d"}, 1)
+	if _, ok := c.get("a"); ok || len(c.entries) != 1 || c.order.Len() != 1 {
+		t.Error("cache is not shrunk to smaller size")
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	automaton := func(p, q dfa.State) *dfa.DFA {
+		m := dfa.New()
+		m.SetTransition(p, "a", q) // nolint: errcheck
+		m.SetTransition(q, "a", p) // nolint: errcheck
+		m.SetStartState(p)
+		m.SetFinalStates(q)
+		return m
+	}
+	attempt, target := automaton("p", "q"), automaton("x", "y")
+	key := cacheKey(attempt, target, "HW1")
+
+	// the same automaton with states listed in other order
+	reordered := dfa.New()
+	reordered.SetTransition("q", "a", "p") // nolint: errcheck
+	reordered.SetTransition("p", "a", "q") // nolint: errcheck
+	reordered.SetStartState("p")
+	reordered.SetFinalStates("q")
+	if cacheKey(reordered, target, "hw1") != key {
+		t.Error("order of states or case of assignment changes key")
+	}
+	// feedback names states, so renamed automata get other responses
+	if cacheKey(automaton("x", "y"), target, "HW1") == key {
+		t.Error("key does not depend on names of states")
+	}
+	if cacheKey(attempt, target, "HW2") == key {
+		t.Error("key does not depend on assignment")
+	}
+	other := automaton("p", "q")
+	other.SetFinalStates("p")
+	if cacheKey(other, target, "HW1") == key {
+		t.Error("key does not depend on automata")
+	}
+
+	config.Generation++
+	if cacheKey(attempt, target, "HW1") == key {
+		t.Error("reading configuration does not invalidate keys")
+	}
+}
@@ -1,14 +1,20 @@
 package server
 
 import (
+	"bytes"
+	"context"
 	"dfa-grader/config"
 	"dfa-grader/dfa"
+	"dfa-grader/format"
 	"dfa-grader/grader"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"math"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,15 +23,21 @@ import (
 )
 
 // dfaHandler may hold any specific variables needed for this handler
-type dfaHandler struct{}
+type dfaHandler struct {
+	cache *gradeCache
+}
 
 func newDFAHandler() *dfaHandler {
-	return &dfaHandler{}
+	return &dfaHandler{
+		cache: newGradeCache(),
+	}
 }
 
 // register adds endpoints to this handler
 func (h *dfaHandler) register(r *mux.Router) {
 	r.HandleFunc("/grade", h.handleDFATest).Methods(http.MethodPost)
+	r.HandleFunc("/simulate", h.handleSimulate).Methods(http.MethodPost)
+	r.HandleFunc("/render", h.handleRender).Methods(http.MethodPost)
 }
 
 func (h *dfaHandler) handleDFATest(w http.ResponseWriter, r *http.Request) {
@@ -42,11 +54,7 @@ func (h *dfaHandler) handleDFATest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// validate data
-	var data struct {
-		Attempt automata `json:"attempt"`
-		Target  automata `json:"target"`
-	}
-	err = json.Unmarshal(body, &data)
+	data, err := decodeGradeRequest(r, body)
 	if err != nil {
 		w.WriteHeader(http.StatusUnprocessableEntity)
 		resp := response{
@@ -61,7 +69,10 @@ func (h *dfaHandler) handleDFATest(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 	fmt.Println("Received grading request")
 
-	dfaAttempt, err := createDFA(data.Attempt)
+	dfaAttempt, err := decodeAutomaton(data.Format, data.Attempt)
+	if err == nil && !isJSONFormat(data.Format) {
+		err = extendAlphabet(dfaAttempt, data.Alphabet)
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusUnprocessableEntity)
 		resp := response{
@@ -72,7 +83,10 @@ func (h *dfaHandler) handleDFATest(w http.ResponseWriter, r *http.Request) {
 		encodeResponse(w, &resp)
 		return
 	}
-	dfaTarget, err := createDFA(data.Target)
+	dfaTarget, err := createTarget(
+		data.Format, data.Target, data.TargetRegex,
+		dfaAttempt.Compile().Letters(),
+	)
 	if err != nil {
 		w.WriteHeader(http.StatusUnprocessableEntity)
 		resp := response{
@@ -83,6 +97,19 @@ func (h *dfaHandler) handleDFATest(w http.ResponseWriter, r *http.Request) {
 		encodeResponse(w, &resp)
 		return
 	}
+	if !isJSONFormat(data.Format) {
+		err = mergeAlphabets(dfaAttempt, dfaTarget)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			resp := response{
+				Status:  "fail",
+				Message: "Automata have incompatible alphabets",
+				Error:   err.Error(),
+			}
+			encodeResponse(w, &resp)
+			return
+		}
+	}
 	err = dfaAttempt.Determinize()
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -106,13 +133,24 @@ func (h *dfaHandler) handleDFATest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	dfaAttemptMin := dfaAttempt.Copy()
-	dfaTargetMin := dfaTarget.Copy()
+	key := cacheKey(dfaAttempt, dfaTarget, data.Assignment)
+	if resp, ok := h.cache.get(key); config.CacheSize > 0 && ok {
+		fmt.Println("Grade found in cache")
+		resp.Cached = true
+		w.WriteHeader(http.StatusOK)
+		encodeResponse(w, &resp)
+		return
+	}
+	attemptHash := dfaAttempt.LanguageHash()
 
-	dfaAttemptMin.Minimize()
-	dfaTargetMin.Minimize()
+	// grading is aborted when client disconnects
+	ctx := r.Context()
 
-	eq, err := dfa.Compare(dfaAttemptMin, dfaTargetMin)
+	eq, err := dfa.Compare(ctx, dfaAttempt, dfaTarget)
+	if ctx.Err() != nil {
+		fmt.Println("Grading aborted:", ctx.Err().Error())
+		return
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		resp := response{
@@ -130,47 +168,263 @@ func (h *dfaHandler) handleDFATest(w http.ResponseWriter, r *http.Request) {
 			Message:    "Graded automata",
 			MaxScore:   config.MaxScore,
 			TotalScore: config.MaxScore,
+
+			TotalDerivation:  "attempt is equivalent to target",
+			LanguageRelation: grader.RelationEqual.String(),
+			StateMapping:     stateMapping(ctx, dfaAttempt, dfaTarget),
+			AttemptHash:      attemptHash,
 		}
+		h.store(key, resp)
 		encodeResponse(w, &resp)
 		return
 	}
 
-	var scaledLangDiffScore, scaledDFASyntaxDiffScore float64
-	wg := &sync.WaitGroup{}
-	wg.Add(1)
-	go func() {
-		langDiffScore := grader.GetLanguageDifference(dfaAttempt, dfaTarget)
-
-		scaledLangDiffScore = config.MaxScore * langDiffScore
-
-		wg.Done()
-	}()
-
-	wg.Add(1)
-	go func() {
-		dfaSyntaxDiffScore := grader.GetDFASyntaxDifference(dfaAttempt, dfaTarget)
-
-		scaledDFASyntaxDiffScore = config.MaxScore * dfaSyntaxDiffScore
+	methods, err := gradingMethods()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := response{
+			Status:  "fail",
+			Message: "Invalid grading configuration",
+			Error:   err.Error(),
+		}
+		encodeResponse(w, &resp)
+		return
+	}
+	results := runMethods(ctx, methods, dfaAttempt, dfaTarget)
+	if ctx.Err() != nil {
+		fmt.Println("Grading aborted:", ctx.Err().Error())
+		return
+	}
+	combination := config.Grading.CombinationFor(data.Assignment)
+	total, derivation := combination.Combine(unscaledScores(results))
+	totalScore := config.MaxScore * total
+	fmt.Println("Total time to compute grade:", time.Since(start))
 
-		wg.Done()
-	}()
-	wg.Wait()
+	attemptRegex, targetRegex := feedbackRegexes(ctx, dfaAttempt, dfaTarget)
 
-	totalScore := math.Max(scaledLangDiffScore, scaledDFASyntaxDiffScore)
-	fmt.Println("Total time to compute grade:", time.Since(start))
+	relation := grader.GetLanguageRelation(dfaAttempt, dfaTarget)
 
 	resp := response{
 		Status:        "ok",
 		Message:       "Graded automata",
 		MaxScore:      config.MaxScore,
 		TotalScore:    totalScore,
-		LangDiffScore: scaledLangDiffScore,
-		DFADiffScore:  scaledDFASyntaxDiffScore,
+		LangDiffScore: config.MaxScore * results[grader.LangDiffName].Score,
+		DFADiffScore:  config.MaxScore * results[grader.DFASyntaxDiffName].Score,
+		DFADiffEdits:  describeEdits(results[grader.DFASyntaxDiffName].Edits),
+		AttemptRegex:  attemptRegex,
+		TargetRegex:   targetRegex,
+		AttemptDOT:    dfaAttempt.GraphVizWithOptions(feedbackGraphViz),
+		TargetDOT:     dfaTarget.GraphVizWithOptions(feedbackGraphViz),
+
+		DensityDiffScore: config.MaxScore * results[grader.DensityDiffName].Score,
+		LanguageRelation: relation.String(),
+		StateMapping:     stateMapping(ctx, dfaAttempt, dfaTarget),
+		Scores:           scaleScores(results),
+		Methods:          methodStatuses(results),
+		NeedsReview:      needsReview(results),
+		Deterministic:    config.Deterministic,
+		TotalDerivation:  derivation,
+		AttemptHash:      attemptHash,
+
+		Counterexamples: findCounterexamples(dfaAttempt, dfaTarget),
 	}
+	h.store(key, resp)
 	w.WriteHeader(http.StatusOK)
 	encodeResponse(w, &resp)
 }
 
+// cacheKey identifies grading request. Compiled automata do not depend on
+// order in which states and transitions were listed, configuration
+// generation makes responses graded before configuration reload stale
+func cacheKey(attempt, target *dfa.DFA, assignment string) string {
+	return fmt.Sprintf(
+		"%d:%s:%s:%s",
+		config.Generation,
+		attempt.Compile().Hash(),
+		target.Compile().Hash(),
+		strings.ToLower(assignment),
+	)
+}
+
+// store caches response unless it is based on partial calculation, which
+// could give a better grade with more time
+func (h *dfaHandler) store(key string, resp response) {
+	if config.CacheSize <= 0 || resp.NeedsReview {
+		return
+	}
+	h.cache.put(key, resp, config.CacheSize)
+}
+
+// feedbackGraphViz hides sink states added to automata before grading
+var feedbackGraphViz = dfa.GraphVizOptions{HideSink: true}
+
+// gradingMethods looks up methods listed in configuration
+func gradingMethods() ([]grader.Method, error) {
+	methods := make([]grader.Method, 0, len(config.Grading.Methods))
+	for _, name := range config.Grading.Methods {
+		m, ok := grader.Lookup(name)
+		if !ok {
+			return nil, errors.Errorf("unknown grading method '%s'", name)
+		}
+		methods = append(methods, m)
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("no grading methods configured")
+	}
+	return methods, nil
+}
+
+// runMethods scores attempt with all methods in parallel, methods that fail
+// are logged and get zero score
+func runMethods(
+	ctx context.Context,
+	methods []grader.Method,
+	attempt, target *dfa.DFA,
+) map[string]grader.Result {
+	results := make(map[string]grader.Result, len(methods))
+	mu := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	for _, m := range methods {
+		wg.Add(1)
+		go func(m grader.Method) {
+			defer wg.Done()
+			res, err := m.Score(ctx, attempt, target)
+			if err != nil {
+				fmt.Printf("Method %s failed: %s\n", m.Name(), err.Error())
+			}
+			mu.Lock()
+			results[m.Name()] = res
+			mu.Unlock()
+		}(m)
+	}
+	wg.Wait()
+	return results
+}
+
+func unscaledScores(results map[string]grader.Result) map[string]float64 {
+	scores := make(map[string]float64, len(results))
+	for name, res := range results {
+		scores[name] = res.Score
+	}
+	return scores
+}
+
+func scaleScores(results map[string]grader.Result) map[string]float64 {
+	scores := make(map[string]float64, len(results))
+	for name, res := range results {
+		scores[name] = config.MaxScore * res.Score
+	}
+	return scores
+}
+
+func methodStatuses(results map[string]grader.Result) map[string]methodStatus {
+	statuses := make(map[string]methodStatus, len(results))
+	for name, res := range results {
+		statuses[name] = methodStatus{
+			Completed:      res.Stats.Completed,
+			TimedOut:       res.Stats.TimedOut,
+			OutOfBudget:    res.Stats.OutOfBudget,
+			CompletedDepth: res.Stats.CompletedDepth,
+			WordsExamined:  res.Stats.WordsExamined,
+			NodesExamined:  res.Stats.NodesExamined,
+			ElapsedMS:      res.Stats.Elapsed.Seconds() * 1000,
+		}
+	}
+	return statuses
+}
+
+// needsReview checks if grade is based on partial calculation
+func needsReview(results map[string]grader.Result) bool {
+	for _, res := range results {
+		if !res.Stats.Completed {
+			return true
+		}
+	}
+	return false
+}
+
+// feedbackRegexes converts both automata to regular expressions within
+// configured limits. Conversions run in parallel under one shared deadline,
+// so feedback adds at most the configured timeout to grading. Expression is
+// left empty if it is too long or takes too long to find. Like grading
+// methods, conversions are not limited by time in deterministic mode
+func feedbackRegexes(
+	ctx context.Context,
+	attempt, target *dfa.DFA,
+) (string, string) {
+	var cancel context.CancelFunc
+	if config.Deterministic {
+		ctx, cancel = context.WithCancel(ctx)
+	} else {
+		ctx, cancel = context.WithTimeout(ctx, config.Regex.Timeout)
+	}
+	defer cancel()
+
+	var attemptRegex, targetRegex string
+	wg := &sync.WaitGroup{}
+	convert := func(m *dfa.DFA, name string, regex *string) {
+		defer wg.Done()
+		r, err := m.ToRegex(ctx, config.Regex.MaxLength)
+		if err != nil {
+			fmt.Printf("Could not convert %s to regex: %s\n", name, err.Error())
+			return
+		}
+		*regex = r
+	}
+	wg.Add(2)
+	go convert(attempt, "attempt", &attemptRegex)
+	go convert(target, "target", &targetRegex)
+	wg.Wait()
+	return attemptRegex, targetRegex
+}
+
+// stateMapping pairs states of automata after minimization, so that
+// students see which of their states corresponds to which target state. If
+// automata are not equivalent, mapping is partial: states reached with the
+// same word are paired as long as they agree, see dfa.Isomorphism
+func stateMapping(ctx context.Context, attempt, target *dfa.DFA) map[string]string {
+	attemptMin := attempt.Copy()
+	targetMin := target.Copy()
+	if attemptMin.Minimize(ctx) != nil || targetMin.Minimize(ctx) != nil {
+		return nil
+	}
+	mapping, _ := dfa.Isomorphism(attemptMin, targetMin)
+
+	result := make(map[string]string, len(mapping))
+	for s, t := range mapping {
+		result[string(s)] = string(t)
+	}
+	return result
+}
+
+func describeEdits(edits []grader.Edit) []string {
+	result := make([]string, 0, len(edits))
+	for _, e := range edits {
+		result = append(result, e.String())
+	}
+	return result
+}
+
+// findCounterexamples lists shortest words on which attempt and target
+// disagree, labeled with the answer expected by target
+func findCounterexamples(attempt, target *dfa.DFA) []counterexample {
+	words := dfa.DistinguishingWords(attempt, target, config.Counterexamples)
+	result := make([]counterexample, 0, len(words))
+	for _, word := range words {
+		c := counterexample{
+			Word:     word.String(),
+			Letters:  word.Strings(),
+			Expected: expectReject,
+		}
+		if target.Accepts(word) {
+			c.Expected = expectAccept
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
 func encodeResponse(w http.ResponseWriter, data interface{}) {
 	err := json.NewEncoder(w).Encode(data)
 	if err != nil {
@@ -178,36 +432,249 @@ func encodeResponse(w http.ResponseWriter, data interface{}) {
 	}
 }
 
-// nolint: gocyclo
-func createDFA(a automata) (*dfa.DFA, error) {
-	m := dfa.New()
+// mediaFormats are formats of attempted automaton sent as request body.
+// Tables have their own media type, because clients such as browsers send
+// JSON bodies as text/plain by default
+var mediaFormats = map[string]string{
+	"application/xml":    formatJFLAP,
+	"text/xml":           formatJFLAP,
+	"text/vnd.graphviz":  formatDOT,
+	"text/vnd.dfa-table": formatTable,
+}
 
+// decodeGradeRequest reads grading request. Request body may be attempted
+// automaton in one of mediaFormats instead of JSON, then target regex,
+// assignment and alphabet are given in query. To send the target in the same
+// format, body is multipart/form-data with parts named attempt and target,
+// each with Content-Type of the format
+func decodeGradeRequest(r *http.Request, body []byte) (gradeRequest, error) {
+	var data gradeRequest
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err == nil && mediaType == "multipart/form-data" {
+		err = decodeGradeParts(&data, body, params["boundary"])
+		if err != nil {
+			return data, err
+		}
+		decodeGradeQuery(&data, r)
+		return data, nil
+	}
+	f, ok := mediaFormats[mediaType]
+	if err != nil || !ok {
+		err = json.Unmarshal(body, &data)
+		return data, err
+	}
+
+	attempt, err := json.Marshal(string(body))
+	if err != nil {
+		return data, err
+	}
+	data.Format = f
+	data.Attempt = attempt
+	decodeGradeQuery(&data, r)
+	return data, nil
+}
+
+// decodeGradeQuery reads fields of grading request given in query
+func decodeGradeQuery(data *gradeRequest, r *http.Request) {
+	query := r.URL.Query()
+	data.TargetRegex = query.Get("target_regex")
+	data.Assignment = query.Get("assignment")
+	data.Alphabet = query["alphabet"]
+}
+
+// decodeGradeParts reads attempt and target from parts of multipart body,
+// both automata have to be in the same format
+func decodeGradeParts(data *gradeRequest, body []byte, boundary string) error {
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		mediaType, _, err := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		f, ok := mediaFormats[mediaType]
+		if err != nil || !ok {
+			return errors.Errorf(
+				"part '%s' has unknown content type '%s'",
+				part.FormName(), part.Header.Get("Content-Type"),
+			)
+		}
+		if data.Format != "" && data.Format != f {
+			return errors.New("attempt and target should have the same format")
+		}
+		data.Format = f
+
+		contents, err := ioutil.ReadAll(part)
+		if err != nil {
+			return err
+		}
+		raw, err := json.Marshal(string(contents))
+		if err != nil {
+			return err
+		}
+		switch part.FormName() {
+		case "attempt":
+			data.Attempt = raw
+		case "target":
+			data.Target = raw
+		default:
+			return errors.Errorf("unknown part '%s'", part.FormName())
+		}
+	}
+	if !isGiven(data.Attempt) {
+		return errors.New("part 'attempt' should be given")
+	}
+	return nil
+}
+
+func isJSONFormat(f string) bool {
+	f = strings.ToLower(f)
+	return f == "" || f == formatJSON
+}
+
+// isGiven checks if automaton is present in request
+func isGiven(raw json.RawMessage) bool {
+	return len(raw) > 0 && string(raw) != "null"
+}
+
+// decodeAutomaton builds DFA from automaton encoded in given format
+func decodeAutomaton(f string, raw json.RawMessage) (*dfa.DFA, error) {
+	if isJSONFormat(f) {
+		var a automata
+		if isGiven(raw) {
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return nil, err
+			}
+		}
+		return createAutomaton(a)
+	}
+
+	var file string
+	if err := json.Unmarshal(raw, &file); err != nil || file == "" {
+		return nil, errors.Errorf(
+			"automaton in format '%s' should be given as string", f,
+		)
+	}
+	switch strings.ToLower(f) {
+	case formatJFLAP:
+		return format.ReadJFLAP(strings.NewReader(file))
+	case formatDOT:
+		return format.ReadDOT(strings.NewReader(file))
+	case formatTable:
+		return dfa.ParseTable(file)
+	default:
+		return nil, errors.Errorf("unknown format '%s'", f)
+	}
+}
+
+// extendAlphabet adds letters to alphabet of automaton
+func extendAlphabet(m *dfa.DFA, letters []string) error {
+	for _, l := range letters {
+		m.SetLetter(dfa.Letter(l))
+	}
+	return dfa.ValidateAlphabet(m.Alphabet())
+}
+
+// mergeAlphabets adds letters of each automaton to the other one, automata
+// read from files of other tools know only letters their transitions read
+func mergeAlphabets(m1, m2 *dfa.DFA) error {
+	for _, l := range m1.Alphabet() {
+		m2.SetLetter(l)
+	}
+	for _, l := range m2.Alphabet() {
+		m1.SetLetter(l)
+	}
+	return dfa.ValidateAlphabet(m1.Alphabet())
+}
+
+// automatonBuilder is implemented by both deterministic and
+// nondeterministic automata, so request data can be validated the same way
+type automatonBuilder interface {
+	SetLetter(l dfa.Letter)
+	SetState(q dfa.State)
+	SetStartState(q0 dfa.State)
+	SetFinalStates(f ...dfa.State)
+	HasState(s dfa.State) bool
+	HasLetter(l dfa.Letter) bool
+}
+
+// createAutomaton builds DFA from request data, nondeterministic automata are
+// converted using subset construction
+func createAutomaton(a automata) (*dfa.DFA, error) {
+	switch a.Type {
+	case "", automataTypeDFA:
+		return createDFA(a)
+	case automataTypeNFA:
+		m, err := createNFA(a)
+		if err != nil {
+			return nil, err
+		}
+		return m.ToDFA()
+	default:
+		return nil, errors.Errorf("unknown automata type '%s'", a.Type)
+	}
+}
+
+// createTarget builds expected DFA either from automaton in given format or
+// from regular expression over the alphabet of attempted automaton
+func createTarget(
+	f string,
+	target json.RawMessage,
+	targetRegex string,
+	alphabet []dfa.Letter,
+) (*dfa.DFA, error) {
+	if isGiven(target) && targetRegex != "" {
+		return nil, errors.New(
+			"only one of target and target_regex should be given",
+		)
+	}
+	if isGiven(target) {
+		return decodeAutomaton(f, target)
+	}
+	if targetRegex == "" {
+		return nil, errors.New("target or target_regex should be given")
+	}
+	return dfa.CompileRegex(targetRegex, alphabet)
+}
+
+// nolint: gocyclo
+func fillAutomaton(m automatonBuilder, a automata) error {
 	if len(a.Alphabet) == 0 {
-		return nil, errors.New("alphabet should not be empty")
+		return errors.New("alphabet should not be empty")
 	}
+	alphabet := make([]dfa.Letter, 0, len(a.Alphabet))
 	for _, l := range a.Alphabet {
-		m.SetLetter(dfa.Letter(l))
+		alphabet = append(alphabet, dfa.Letter(l))
+	}
+	if err := dfa.ValidateAlphabet(alphabet); err != nil {
+		return err
+	}
+	for _, l := range alphabet {
+		m.SetLetter(l)
 	}
 
 	if len(a.States) == 0 {
-		return nil, errors.New("automata should have at least one state")
+		return errors.New("automata should have at least one state")
 	}
 	for _, s := range a.States {
 		m.SetState(dfa.State(s))
 	}
 
 	if a.StartState == "" {
-		return nil, errors.New("start state should not be empty")
+		return errors.New("start state should not be empty")
 	}
 	if !m.HasState(dfa.State(a.StartState)) {
-		return nil, errors.New("start state not in list of states")
+		return errors.New("start state not in list of states")
 	}
 	m.SetStartState(dfa.State(a.StartState))
 
 	finals := []dfa.State{}
 	for _, f := range a.FinalStates {
 		if !m.HasState(dfa.State(f)) {
-			return nil, errors.Errorf(
+			return errors.Errorf(
 				"final state '%s' not in list of states", f,
 			)
 		}
@@ -217,18 +684,71 @@ func createDFA(a automata) (*dfa.DFA, error) {
 
 	for _, t := range a.Transitions {
 		if !m.HasState(dfa.State(t.From)) {
-			return nil, errors.Errorf(
+			return errors.Errorf(
 				"transition state '%s' not in list of states", t.From,
 			)
 		}
 		if !m.HasState(dfa.State(t.To)) {
-			return nil, errors.Errorf(
+			return errors.Errorf(
 				"transition state '%s' not in list of states", t.To,
 			)
 		}
+	}
+
+	return nil
+}
+
+func createDFA(a automata) (*dfa.DFA, error) {
+	m := dfa.New()
+	err := fillAutomaton(m, a)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range a.Transitions {
+		if !m.HasLetter(dfa.Letter(t.Symbol)) {
+			return nil, errors.Errorf(
+				"transition symbol '%s' not in alphabet", t.Symbol,
+			)
+		}
+		to, err := m.TransitionTarget(dfa.State(t.From), dfa.Letter(t.Symbol))
+		if err == nil && to != dfa.State(t.To) {
+			return nil, errors.Errorf(
+				"state '%s' has more than one transition with symbol '%s', "+
+					"use type '%s' for nondeterministic automata",
+				t.From, t.Symbol, automataTypeNFA,
+			)
+		}
+		m.SetTransition( // nolint: errcheck
+			dfa.State(t.From),
+			dfa.Letter(t.Symbol),
+			dfa.State(t.To),
+		)
+	}
+
+	return m, nil
+}
+
+// createNFA builds NFA from request data, transitions without symbol are
+// treated as epsilon transitions
+func createNFA(a automata) (*dfa.NFA, error) {
+	m := dfa.NewNFA()
+	err := fillAutomaton(m, a)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range a.Transitions {
+		if t.Symbol == "" {
+			m.SetEpsilonTransition( // nolint: errcheck
+				dfa.State(t.From),
+				dfa.State(t.To),
+			)
+			continue
+		}
 		if !m.HasLetter(dfa.Letter(t.Symbol)) {
 			return nil, errors.Errorf(
-				"transition symbol '%s' not in list of states", t.Symbol,
+				"transition symbol '%s' not in alphabet", t.Symbol,
 			)
 		}
 		m.SetTransition( // nolint: errcheck
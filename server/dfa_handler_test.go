@@ -0,0 +1,247 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"dfa-grader/config"
+	"dfa-grader/dfa"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+func TestCreateDFA(t *testing.T) {
+	tests := []struct {
+		name        string
+		transitions []transition
+		err         string
+	}{
+		{
+			name: "deterministic",
+			transitions: []transition{
+				{From: "p", To: "q", Symbol: "a"},
+				{From: "q", To: "q", Symbol: "a"},
+			},
+		},
+		{
+			name: "identical duplicate",
+			transitions: []transition{
+				{From: "p", To: "q", Symbol: "a"},
+				{From: "p", To: "q", Symbol: "a"},
+			},
+		},
+		{
+			name: "nondeterministic",
+			transitions: []transition{
+				{From: "p", To: "q", Symbol: "a"},
+				{From: "p", To: "p", Symbol: "a"},
+			},
+			err: "more than one transition",
+		},
+		{
+			name:        "letter not in alphabet",
+			transitions: []transition{{From: "p", To: "q", Symbol: "b"}},
+			err:         "not in alphabet",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := createDFA(automata{
+				Alphabet:    []string{"a"},
+				States:      []string{"p", "q"},
+				StartState:  "p",
+				FinalStates: []string{"q"},
+				Transitions: tt.transitions,
+			})
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if to, _ := m.TransitionTarget("p", "a"); to != "q" {
+				t.Errorf("p goes to %s with a, want q", to)
+			}
+		})
+	}
+}
+
+func TestFeedbackRegexes(t *testing.T) {
+	if err := config.Read(""); err != nil {
+		t.Fatal(err)
+	}
+	attempt, target := dfa.New(), dfa.New()
+	attempt.SetTransition("p", "a", "p") // nolint: errcheck
+	attempt.SetStartState("p")
+	attempt.SetFinalStates("p")
+	target.SetTransition("p", "a", "q") // nolint: errcheck
+	target.SetStartState("p")
+	target.SetFinalStates("q")
+
+	attemptRegex, targetRegex := feedbackRegexes(
+		context.Background(), attempt, target,
+	)
+	if attemptRegex != "a*" || targetRegex != "a" {
+		t.Errorf("got regexes %q and %q, want a* and a", attemptRegex, targetRegex)
+	}
+
+	// both conversions share the deadline, neither is found once it passes
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	attemptRegex, targetRegex = feedbackRegexes(ctx, attempt, target)
+	if attemptRegex != "" || targetRegex != "" {
+		t.Errorf("got regexes %q and %q after deadline", attemptRegex, targetRegex)
+	}
+}
+
+// multipartBody encodes automata as parts of multipart/form-data body, parts
+// are given as name, content type and contents
+func multipartBody(t *testing.T, parts ...[3]string) (string, string) {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for _, p := range parts {
+		header := textproto.MIMEHeader{}
+		header.Set(
+			"Content-Disposition",
+			fmt.Sprintf(`form-data; name="%s"`, p[0]),
+		)
+		header.Set("Content-Type", p[1])
+		part, err := w.CreatePart(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write([]byte(p[2])) // nolint: errcheck
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return body.String(), w.FormDataContentType()
+}
+
+func TestDecodeGradeRequest(t *testing.T) {
+	const (
+		attempt = "  | a\n→p | p\n"
+		target  = "  | a\n→*p | p\n"
+	)
+	tablePart := func(name, contents string) [3]string {
+		return [3]string{name, "text/vnd.dfa-table", contents}
+	}
+	both, bothType := multipartBody(
+		t, tablePart("attempt", attempt), tablePart("target", target),
+	)
+	onlyAttempt, onlyAttemptType := multipartBody(
+		t, tablePart("attempt", attempt),
+	)
+	mixed, mixedType := multipartBody(
+		t, tablePart("attempt", attempt),
+		[3]string{"target", "text/vnd.graphviz", "digraph {}"},
+	)
+	noAttempt, noAttemptType := multipartBody(t, tablePart("target", target))
+
+	tests := []struct {
+		name        string
+		query       string
+		contentType string
+		body        string
+		format      string
+		target      bool
+		targetRegex string
+		err         string
+	}{
+		{
+			name:        "json sent as plain text",
+			contentType: "text/plain;charset=UTF-8",
+			body:        `{"format":"table","attempt":"x","target":"y"}`,
+			format:      formatTable,
+			target:      true,
+		},
+		{
+			name:        "table body",
+			query:       "?target_regex=a*",
+			contentType: "text/vnd.dfa-table; charset=utf-8",
+			body:        attempt,
+			format:      formatTable,
+			targetRegex: "a*",
+		},
+		{
+			name:        "multipart with target",
+			contentType: bothType,
+			body:        both,
+			format:      formatTable,
+			target:      true,
+		},
+		{
+			name:        "multipart with target regex",
+			query:       "?target_regex=a*",
+			contentType: onlyAttemptType,
+			body:        onlyAttempt,
+			format:      formatTable,
+			targetRegex: "a*",
+		},
+		{
+			name:        "multipart with different formats",
+			contentType: mixedType,
+			body:        mixed,
+			err:         "same format",
+		},
+		{
+			name:        "multipart without attempt",
+			contentType: noAttemptType,
+			body:        noAttempt,
+			err:         "'attempt' should be given",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(
+				http.MethodPost, "/grade"+tt.query, strings.NewReader(tt.body),
+			)
+			r.Header.Set("Content-Type", tt.contentType)
+			data, err := decodeGradeRequest(r, []byte(tt.body))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data.Format != tt.format || isGiven(data.Target) != tt.target ||
+				data.TargetRegex != tt.targetRegex || !isGiven(data.Attempt) {
+				t.Errorf("got request %+v", data)
+			}
+		})
+	}
+}
+
+func TestGradeTables(t *testing.T) {
+	if err := config.Read(""); err != nil {
+		t.Fatal(err)
+	}
+	body, contentType := multipartBody(t,
+		[3]string{"attempt", "text/vnd.dfa-table", "  | a\n→*p | q\nq | p\n"},
+		[3]string{"target", "text/vnd.dfa-table", "  | a | b\n→*x | y | -\ny | x | -\n"},
+	)
+	r := httptest.NewRequest(http.MethodPost, "/grade", strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, r)
+
+	var resp response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || resp.TotalScore != resp.MaxScore {
+		t.Errorf("got status %d and response %+v", w.Code, resp)
+	}
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"bytes"
+	"dfa-grader/dfa"
+	"dfa-grader/render"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	layoutLayered  = "layered"
+	layoutCircular = "circular"
+
+	defaultWordColor = "orange"
+)
+
+func (h *dfaHandler) handleRender(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 1024*1024*10))
+	if err != nil {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		resp := response{
+			Status:  "fail",
+			Message: "Request data too large",
+			Error:   err.Error(),
+		}
+		encodeResponse(w, &resp)
+		return
+	}
+
+	var data struct {
+		Format    string          `json:"format"`
+		Automaton json.RawMessage `json:"automaton"`
+		Layout    string          `json:"layout"`
+		HideSink  bool            `json:"hide_sink"`
+		Highlight highlight       `json:"highlight"`
+	}
+	err = json.Unmarshal(body, &data)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		resp := response{
+			Status:  "fail",
+			Message: "Unable to process request data",
+			Error:   err.Error(),
+		}
+		encodeResponse(w, &resp)
+		return
+	}
+
+	m, err := decodeAutomaton(data.Format, data.Automaton)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		resp := response{
+			Status:  "fail",
+			Message: "Unable to create DFA",
+			Error:   err.Error(),
+		}
+		encodeResponse(w, &resp)
+		return
+	}
+
+	opts, err := renderOptions(m, data.Layout, data.HideSink, data.Highlight)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		resp := response{
+			Status:  "fail",
+			Message: "Invalid rendering options",
+			Error:   err.Error(),
+		}
+		encodeResponse(w, &resp)
+		return
+	}
+
+	var buf bytes.Buffer
+	err = render.SVG(&buf, m, opts)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := response{
+			Status:  "fail",
+			Message: "Unable to render DFA",
+			Error:   err.Error(),
+		}
+		encodeResponse(w, &resp)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes()) // nolint: errcheck,gas
+}
+
+// renderOptions checks that highlighted states and transitions exist
+func renderOptions(
+	m *dfa.DFA,
+	layout string,
+	hideSink bool,
+	h highlight,
+) (render.Options, error) {
+	opts := render.Options{
+		HideSink:         hideSink,
+		StateColors:      make(map[dfa.State]string, len(h.States)),
+		TransitionColors: make(map[render.Transition]string),
+	}
+	switch strings.ToLower(layout) {
+	case "", layoutLayered:
+		opts.Layout = render.Layered
+	case layoutCircular:
+		opts.Layout = render.Circular
+	default:
+		return opts, errors.Errorf("unknown layout '%s'", layout)
+	}
+
+	if len(h.Word) > 0 || h.Input != nil {
+		word, err := simulatedWord(m, h.Word, h.Input)
+		if err != nil {
+			return opts, err
+		}
+		color := h.Color
+		if color == "" {
+			color = defaultWordColor
+		}
+		opts.HighlightWord(m, word, color)
+	}
+
+	for s, color := range h.States {
+		if !m.HasState(dfa.State(s)) {
+			return opts, errors.Errorf("state '%s' not in list of states", s)
+		}
+		opts.StateColors[dfa.State(s)] = color
+	}
+	for _, t := range h.Transitions {
+		if !m.HasState(dfa.State(t.From)) {
+			return opts, errors.Errorf(
+				"transition state '%s' not in list of states", t.From,
+			)
+		}
+		if !m.HasLetter(dfa.Letter(t.Symbol)) {
+			return opts, errors.Errorf(
+				"transition symbol '%s' not in alphabet", t.Symbol,
+			)
+		}
+		opts.TransitionColors[render.Transition{
+			From:   dfa.State(t.From),
+			Letter: dfa.Letter(t.Symbol),
+		}] = t.Color
+	}
+	return opts, nil
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"dfa-grader/dfa"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+func (h *dfaHandler) handleSimulate(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 1024*1024*10))
+	if err != nil {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		resp := simulateResponse{
+			Status:  "fail",
+			Message: "Request data too large",
+			Error:   err.Error(),
+		}
+		encodeResponse(w, &resp)
+		return
+	}
+
+	var data struct {
+		Automaton automata `json:"automaton"`
+		Word      []string `json:"word"`
+		Input     *string  `json:"input"`
+	}
+	err = json.Unmarshal(body, &data)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		resp := simulateResponse{
+			Status:  "fail",
+			Message: "Unable to process request data",
+			Error:   err.Error(),
+		}
+		encodeResponse(w, &resp)
+		return
+	}
+
+	m, err := createAutomaton(data.Automaton)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		resp := simulateResponse{
+			Status:  "fail",
+			Message: "Unable to create DFA",
+			Error:   err.Error(),
+		}
+		encodeResponse(w, &resp)
+		return
+	}
+
+	word, err := simulatedWord(m, data.Word, data.Input)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		resp := simulateResponse{
+			Status:  "fail",
+			Message: "Unable to read word",
+			Error:   err.Error(),
+		}
+		encodeResponse(w, &resp)
+		return
+	}
+	trace, err := m.Simulate(word)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		resp := simulateResponse{
+			Status:  "fail",
+			Message: "Unable to simulate DFA",
+			Error:   err.Error(),
+		}
+		encodeResponse(w, &resp)
+		return
+	}
+
+	resp := simulateResponse{
+		Status:   "ok",
+		Message:  "Simulated automaton",
+		States:   make([]string, 0, len(trace.States)),
+		Halted:   trace.Halted,
+		Accepted: trace.Accepted,
+	}
+	for _, s := range trace.States {
+		resp.States = append(resp.States, string(s))
+	}
+	w.WriteHeader(http.StatusOK)
+	encodeResponse(w, &resp)
+}
+
+// simulatedWord takes word either as list of letters or as string that is
+// split into letters of automaton's alphabet
+func simulatedWord(m *dfa.DFA, letters []string, input *string) (dfa.Word, error) {
+	if input == nil {
+		word := make(dfa.Word, 0, len(letters))
+		for _, l := range letters {
+			word = append(word, dfa.Letter(l))
+		}
+		return word, nil
+	}
+	if len(letters) > 0 {
+		return nil, errors.New("only one of word and input should be given")
+	}
+	return dfa.ParseWord(*input, m.Alphabet())
+}
@@ -1,5 +1,36 @@
 package server
 
+import (
+	"encoding/json"
+	"math/big"
+)
+
+const (
+	automataTypeDFA = "dfa"
+	automataTypeNFA = "nfa"
+)
+
+// formats in which automata may be given
+const (
+	formatJSON  = "json"
+	formatJFLAP = "jflap"
+	formatDOT   = "dot"
+	formatTable = "table"
+)
+
+// gradeRequest holds automata encoded in given format, automata in other
+// formats than JSON are JSON strings with file contents. Files of other
+// tools have no alphabet, so letters that no transition reads are listed in
+// Alphabet
+type gradeRequest struct {
+	Format      string          `json:"format"`
+	Attempt     json.RawMessage `json:"attempt"`
+	Target      json.RawMessage `json:"target"`
+	TargetRegex string          `json:"target_regex"`
+	Assignment  string          `json:"assignment"`
+	Alphabet    []string        `json:"alphabet"`
+}
+
 type transition struct {
 	From   string `json:"from"`
 	To     string `json:"to"`
@@ -7,6 +38,7 @@ type transition struct {
 }
 
 type automata struct {
+	Type        string       `json:"type"`
 	Transitions []transition `json:"transitions"`
 	StartState  string       `json:"start_state"`
 	FinalStates []string     `json:"final_states"`
@@ -14,12 +46,80 @@ type automata struct {
 	States      []string     `json:"states"`
 }
 
+const (
+	expectAccept = "should accept"
+	expectReject = "should reject"
+)
+
+// counterexample is a word on which attempted automaton gives wrong answer
+type counterexample struct {
+	Word     string   `json:"word"`
+	Letters  []string `json:"letters"`
+	Expected string   `json:"expected"`
+}
+
+// methodStatus describes how calculation of a single method went
+type methodStatus struct {
+	Completed      bool     `json:"completed"`
+	TimedOut       bool     `json:"timed_out"`
+	OutOfBudget    bool     `json:"out_of_budget,omitempty"`
+	CompletedDepth int      `json:"completed_depth"`
+	WordsExamined  *big.Int `json:"words_examined,omitempty"`
+	NodesExamined  int      `json:"nodes_examined,omitempty"`
+	ElapsedMS      float64  `json:"elapsed_ms"`
+}
+
 type response struct {
-	Status        string  `json:"status"`
-	Message       string  `json:"message"`
-	Error         string  `json:"error,omitempty"`
-	TotalScore    float64 `json:"total_score,omitempty"`
-	MaxScore      float64 `json:"max_score,omitempty"`
-	LangDiffScore float64 `json:"lang_diff_score,omitempty"`
-	DFADiffScore  float64 `json:"dfa_diff_score,omitempty"`
+	Status        string   `json:"status"`
+	Message       string   `json:"message"`
+	Error         string   `json:"error,omitempty"`
+	TotalScore    float64  `json:"total_score,omitempty"`
+	MaxScore      float64  `json:"max_score,omitempty"`
+	LangDiffScore float64  `json:"lang_diff_score,omitempty"`
+	DFADiffScore  float64  `json:"dfa_diff_score,omitempty"`
+	DFADiffEdits  []string `json:"dfa_diff_edits,omitempty"`
+	AttemptRegex  string   `json:"attempt_regex,omitempty"`
+	TargetRegex   string   `json:"target_regex,omitempty"`
+	AttemptDOT    string   `json:"attempt_dot,omitempty"`
+	TargetDOT     string   `json:"target_dot,omitempty"`
+
+	DensityDiffScore float64                 `json:"density_diff_score,omitempty"`
+	LanguageRelation string                  `json:"language_relation,omitempty"`
+	StateMapping     map[string]string       `json:"state_mapping,omitempty"`
+	Scores           map[string]float64      `json:"scores,omitempty"`
+	TotalDerivation  string                  `json:"total_derivation,omitempty"`
+	Methods          map[string]methodStatus `json:"methods,omitempty"`
+	NeedsReview      bool                    `json:"needs_review,omitempty"`
+	Deterministic    bool                    `json:"deterministic,omitempty"`
+	AttemptHash      string                  `json:"attempt_hash,omitempty"`
+	Cached           bool                    `json:"cached,omitempty"`
+
+	Counterexamples []counterexample `json:"counterexamples,omitempty"`
+}
+
+// coloredTransition is transition highlighted in rendered image
+type coloredTransition struct {
+	From   string `json:"from"`
+	Symbol string `json:"symbol"`
+	Color  string `json:"color"`
+}
+
+// highlight lists colors of states and transitions in rendered image, path
+// of a word given either as letters or as string is colored with Color
+type highlight struct {
+	States      map[string]string   `json:"states"`
+	Transitions []coloredTransition `json:"transitions"`
+	Word        []string            `json:"word"`
+	Input       *string             `json:"input"`
+	Color       string              `json:"color"`
+}
+
+// simulateResponse describes run of automaton on a single word
+type simulateResponse struct {
+	Status   string   `json:"status"`
+	Message  string   `json:"message"`
+	Error    string   `json:"error,omitempty"`
+	States   []string `json:"states,omitempty"`
+	Halted   bool     `json:"halted,omitempty"`
+	Accepted bool     `json:"accepted"`
 }